@@ -5,9 +5,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
 
 	"g3-engine/code/controller"
+	"g3-engine/code/input"
 
 	"github.com/jfigge/guilib/graphics"
 )
@@ -19,11 +22,48 @@ const (
 )
 
 func main() {
+	bindingsFile := flag.String("bindings", "", "JSON file mapping actions to keys, mouse buttons and gamepad inputs")
+	recordFile := flag.String("record", "", "file to record input to")
+	replayFile := flag.String("replay", "", "file of recorded input to play back")
+	layoutName := flag.String("layout", "map", "how to split the screen: single, map or quad")
+	timestep := flag.Duration("timestep", 0, "fixed movement step, such as 10ms, to make replays exact")
+	flag.Parse()
+
+	layout, err := controller.ParseLayout(*layoutName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bindings := input.DefaultBindings()
+	if *bindingsFile != "" {
+		if bindings, err = input.LoadBindings(*bindingsFile); err != nil {
+			log.Fatalf("unable to load bindings: %v", err)
+		}
+	}
+	options := []controller.Option{controller.Layout(layout), controller.KeyBindings(bindings)}
+	if *timestep > 0 {
+		options = append(options, controller.FixedTimestep(*timestep))
+	}
+	settings := input.Settings{Timestep: max(0, *timestep), Layout: *layoutName, Bindings: bindings}
+	if *recordFile != "" {
+		recorder, err := input.CreateRecorder(*recordFile, settings)
+		if err != nil {
+			log.Fatalf("unable to record input: %v", err)
+		}
+		options = append(options, controller.Record(recorder))
+	}
+	if *replayFile != "" {
+		player, err := input.OpenPlayer(*replayFile, settings)
+		if err != nil {
+			log.Fatalf("unable to replay input: %v", err)
+		}
+		options = append(options, controller.Replay(player))
+	}
+
 	graphics.Open(
 		"g3 engine",
 		screenWidth*Scale,
 		screenHeight*Scale,
-		controller.NewController(screenWidth/2, screenHeight),
+		controller.NewController(screenWidth/2, screenHeight, options...),
 		graphics.Framerate(60),
 	)
 	fmt.Println("Game over")
@@ -6,7 +6,10 @@ package controller
 
 import (
 	"math"
+	"time"
 
+	"g3-engine/code/input"
+	"g3-engine/code/render"
 	"g3-engine/shapes"
 
 	"github.com/jfigge/guilib/graphics"
@@ -15,9 +18,14 @@ import (
 )
 
 const (
-	FPSX = 605
-	FOV  = 90
-	DOV  = 20
+	FPSX             = 605
+	FOV              = 90
+	DOV              = 20
+	MaxPitch         = math.Pi/2 - 0.01 // just short of straight up or down
+	MouseSensitivity = 0.003            // radians per pixel of mouse movement
+	MaxUpdateGap     = time.Second / 4  // longest update gap movement will cover
+	GamepadDeadZone  = 0.15             // fraction of stick travel ignored around the center
+	GamepadCurve     = 2                // stick response exponent
 )
 
 var (
@@ -47,165 +55,242 @@ const (
 )
 
 type Camera struct {
-	up      *shapes.Vector
-	camera  *shapes.Vector
-	lookDir *shapes.Vector
-	yaw     float64
-	light   *shapes.Vector
+	up          *shapes.Vector
+	camera      *shapes.Vector
+	lookDir     *shapes.Vector
+	yaw         float64
+	pitch       float64 // negative looks up
+	roll        float64
+	perspective *shapes.Matrix4X4
 }
 
 type Fov struct {
 	width  float64
 	height float64
-	cw     float64
-	ch     float64
 	ndov   float64 // near depth of view
 	fdov   float64 // far depth of view
+	aspect float64
+}
+
+type Speeds struct {
+	move float64 // units per second
+	turn float64 // radians per second
 }
 
 type Controller struct {
 	graphics.BaseHandler
 	graphics.CoreMethods
-	camera *Camera
-	fov    *Fov
-	shapes []*shapes.Shape
+	width      float64
+	height     float64
+	layout     LayoutCd
+	viewports  []*Viewport
+	active     int // index of the viewport input moves
+	speeds     *Speeds
+	shapes     []*shapes.Shape
+	lighting   *shapes.Lighting
+	input      *input.Input
+	lastUpdate time.Time
+	fixedStep  *FixedStep
+	recorder   *input.Recorder
+	player     *input.Player
+	previous   input.State
+	mouseLook  bool // the cursor is captured and turns the free-fly camera
+}
+
+type Option func(*Controller)
+
+func MoveSpeed(unitsPerSecond float64) Option {
+	return func(c *Controller) {
+		c.speeds.move = unitsPerSecond
+	}
+}
+
+func TurnSpeed(radiansPerSecond float64) Option {
+	return func(c *Controller) {
+		c.speeds.turn = radiansPerSecond
+	}
+}
+
+// FixedTimestep advances movement in steps of exactly this size, rather than by however
+// long each frame took. A step that isn't positive turns fixed steps off.
+func FixedTimestep(step time.Duration) Option {
+	return func(c *Controller) {
+		if step <= 0 {
+			c.fixedStep = nil
+			return
+		}
+		c.fixedStep = NewFixedStep(step)
+	}
+}
+
+// Layout chooses how the screen is split between viewports
+func Layout(layout LayoutCd) Option {
+	return func(c *Controller) {
+		c.layout = layout
+	}
+}
+
+// Lighting replaces the default sun and lamps, with lights given in world space
+func Lighting(lighting *shapes.Lighting) Option {
+	return func(c *Controller) {
+		c.lighting = lighting
+	}
+}
+
+// KeyBindings replaces the default mapping of keys, mouse buttons and gamepad inputs to actions
+func KeyBindings(bindings input.Bindings) Option {
+	return func(c *Controller) {
+		c.input = input.New(bindings)
+	}
+}
+
+// Record writes every update's input to the recorder, for Replay to play back later
+func Record(recorder *input.Recorder) Option {
+	return func(c *Controller) {
+		c.recorder = recorder
+	}
 }
 
-func NewController(width, height float64) *Controller {
+// Replay drives updates from recorded input instead of the live devices until it runs out.
+// Open the player with the settings the controller is given, so it can reject a recording
+// made with a different timestep, layout or bindings.
+func Replay(player *input.Player) Option {
+	return func(c *Controller) {
+		c.player = player
+	}
+}
+
+func NewController(width, height float64, options ...Option) *Controller {
 	c := &Controller{
-		camera: &Camera{
-			up:      shapes.NewVector(0, 1, 0),
-			camera:  shapes.NewVector(0, 0, 0),
-			lookDir: shapes.NewVector(0, 0, 1),
-			light:   shapes.NewVector(0, 0, -1),
-			yaw:     -math.Pi,
-		},
-		fov: &Fov{
-			width:  width,
-			height: height,
-			cw:     width / 2,
-			ch:     height / 2,
+		width:  width,
+		height: height,
+		layout: LayoutCdMap,
+		speeds: &Speeds{
+			move: 12,
+			turn: 0.6,
 		},
+		lighting: shapes.NewLighting(shapes.RGB{R: 0.1, G: 0.1, B: 0.1},
+			shapes.DirectionalLight(shapes.NewVector(0.3, 0.8, 0.5), shapes.RGB{R: 1, G: 0.95, B: 0.85}, 0.8),
+			shapes.PointLight(shapes.NewVector(-4, -3, 6), shapes.RGB{R: 1, G: 0.6, B: 0.3}, 3, 25),
+			shapes.PointLight(shapes.NewVector(4, -3, 12), shapes.RGB{R: 0.4, G: 0.6, B: 1}, 3, 25),
+		),
+		input: input.New(input.DefaultBindings()),
+	}
+	for _, option := range options {
+		option(c)
 	}
-	f := FOV * math.Pi / 360
-	c.fov.ndov = 0.1  //c.fov.cw * math.Tan(f)
-	c.fov.fdov = 1000 //c.fov.ndov * DOV
-	a := width / height
 
-	shapes := shapes.LoadShapes(shapes.Projection(a, 1/f, c.fov.ndov, c.fov.fdov))
+	shapes, err := shapes.LoadShapes()
+	graphics.ErrorTrap(err)
+	axis, err := shapes.Get("axis")
+	graphics.ErrorTrap(err)
 	c.shapes = append(c.shapes,
-		shapes.Axis(),
-		//Locate(c.fov.cw, c.fov.ch, c.fov.ndov*2).
-		//Rotate(22, 44, 66).
-		//Scale(50, 50, 50),
+		axis.Locate(0, 0, 9),
 	)
+	c.viewports = c.layout.viewports(c)
 	return c
 }
 
 func (c *Controller) Init(canvas *graphics.Canvas) {
 	fonts.LoadFonts(canvas.Renderer())
 	graphics.ErrorTrap(canvas.Renderer().SetDrawBlendMode(sdl.BLENDMODE_BLEND))
-	canvas.Renderer().SetLogicalSize(int32(c.fov.width*2+1), int32(c.fov.height))
+	canvas.Renderer().SetLogicalSize(int32(c.width*2+1), int32(c.height))
 	c.AddDestroyer(fonts.FreeFonts)
+	c.setMouseLook(true)
+	c.AddDestroyer(func() { c.setMouseLook(false) })
+	graphics.ErrorTrap(sdl.InitSubSystem(sdl.INIT_GAMECONTROLLER))
+	gamepad := input.NewGamepad(GamepadDeadZone, GamepadCurve)
+	c.input.SetGamepad(gamepad)
+	c.AddDestroyer(func() {
+		gamepad.Close()
+		sdl.QuitSubSystem(sdl.INIT_GAMECONTROLLER)
+	})
+
+	for _, v := range c.viewports {
+		renderer, err := render.NewSDLRenderer(canvas.Renderer(), v.rect)
+		graphics.ErrorTrap(err)
+		v.renderer = renderer
+		c.AddDestroyer(renderer.Destroy)
+	}
+	if c.recorder != nil {
+		c.AddDestroyer(func() { graphics.ErrorTrap(c.recorder.Close()) })
+	}
 }
 
 func (c *Controller) OnDraw(renderer *sdl.Renderer) {
 	graphics.ErrorTrap(c.Clear(renderer, uint32(0x232323)))
-	c.draw3D(renderer)
+	for _, v := range c.viewports {
+		v.draw(c.shapes, c.lighting)
+	}
 	graphics.ErrorTrap(c.WriteFrameRate(renderer, FPSX, 0))
 }
 
 func (c *Controller) OnUpdate() {
-	c.processKeys()
-}
-
-var xa float64
-
-func (c *Controller) draw3D(renderer *sdl.Renderer) {
-	//xa = xa + .01
-	for _, shape := range c.shapes {
-		ts := shape.GetTriangles(
-			shapes.WorldMatrices(
-				shapes.RotationX(xa*1/2),
-				shapes.RotationY(xa*2/3),
-				shapes.RotationZ(xa),
-				shapes.Translation(0, 0, 9),
-			),
-			shapes.Camera(c.camera.up, c.camera.camera, c.camera.lookDir, c.camera.yaw),
-			shapes.Normal(shapes.NewVector(0, 0, 0)),
-			shapes.Project(),
-			shapes.Center(c.fov.cw, c.fov.ch),
-			shapes.Shade(c.camera.light),
-		)
-
-		var vs []sdl.Vertex
-		for _, t := range ts {
-			vs = append(vs, t.GetVertices()...)
-		}
+	frame := c.sample()
+	if c.recorder != nil {
+		graphics.ErrorTrap(c.recorder.Record(frame))
+	}
+	if frame.State.Pressed(input.NextViewport, c.previous) {
+		c.active = (c.active + 1) % len(c.viewports)
+	}
+	if frame.State.Pressed(input.ToggleOrbit, c.previous) {
+		c.viewports[c.active].toggleOrbit(c.target())
+	}
+	if frame.State.Pressed(input.Projection, c.previous) {
+		c.viewports[c.active].cycleProjection(c.bounds())
+	}
+	if frame.State.Pressed(input.MouseLook, c.previous) {
+		c.setMouseLook(!c.mouseLook)
+	}
+	c.previous = frame.State
+
+	if c.fixedStep != nil {
+		c.fixedStep.Advance(frame.Elapsed, func(dt time.Duration) {
+			c.processInput(frame.State, dt)
+		})
+	} else {
+		c.processInput(frame.State, frame.Elapsed)
+	}
+	c.viewports[c.active].processMouse(frame.State, frame.MouseX, frame.MouseY, c.mouseLook)
+}
+
+// setMouseLook captures the cursor for mouse-look, or releases it to the desktop
+func (c *Controller) setMouseLook(on bool) {
+	c.mouseLook = on
+	sdl.SetRelativeMouseMode(on)
+}
 
-		renderer.RenderGeometry(nil, vs, nil)
-
-		//renderer.SetDrawColor(0, 0, 0, 0xFF)
-		//for _, t := range ts {
-		//	pts := t.GetPoints()
-		//	renderer.DrawLinesF(pts)
-		//}
-	}
-}
-
-func (c *Controller) processKeys() {
-	codes := sdl.GetKeyboardState()
-	//shift := codes[sdl.SCANCODE_LSHIFT] == 1 || codes[sdl.SCANCODE_RSHIFT] == 1
-	if codes[sdl.SCANCODE_UP] == 1 {
-		c.move(DirectionCdMoveUp)
-	} else if codes[sdl.SCANCODE_DOWN] == 1 {
-		c.move(DirectionCdMoveDown)
-	}
-	if codes[sdl.SCANCODE_LEFT] == 1 {
-		c.move(DirectionCdStrafeLeft)
-	} else if codes[sdl.SCANCODE_RIGHT] == 1 {
-		c.move(DirectionCdStrafeRight)
-	}
-	if codes[sdl.SCANCODE_W] == 1 {
-		c.move(DirectionCdForward)
-	} else if codes[sdl.SCANCODE_S] == 1 {
-		c.move(DirectionCdBackward)
-	}
-	if codes[sdl.SCANCODE_A] == 1 {
-		c.move(DirectionCdAntiClockwise)
-	} else if codes[sdl.SCANCODE_D] == 1 {
-		c.move(DirectionCdClockwise)
-	}
-}
-
-func (c *Controller) move(dir DirectionCd) {
-	switch dir {
-	case DirectionCdForward:
-		c.camera.camera = c.camera.camera.Add(c.camera.lookDir.Multiply(.2))
-	case DirectionCdBackward:
-		c.camera.camera = c.camera.camera.Subtract(c.camera.lookDir.Multiply(.2))
-	case DirectionCdStrafeLeft:
-		c.camera.camera.X += .2
-	case DirectionCdStrafeRight:
-		c.camera.camera.X -= .2
-	case DirectionCdMoveUp:
-		c.camera.camera.Y -= .2
-	case DirectionCdMoveDown:
-		c.camera.camera.Y += .2
-	case DirectionCdLookUp:
-		//c.camera.l += 1
-		//if c.camera.l > cameraHeight {
-		//	c.camera.l = cameraHeight
-		//}
-	case DirectionCdLookDown:
-		//c.camera.l -= 1
-		//if c.camera.l < -cameraHeight {
-		//	c.camera.l = -cameraHeight
-		//}
-	case DirectionCdAntiClockwise:
-		c.camera.yaw += .01
-	case DirectionCdClockwise:
-		c.camera.yaw -= .01
+// sample gathers this update's input, from the replay while it lasts or else the live devices
+func (c *Controller) sample() input.Frame {
+	dx, dy, _ := sdl.GetRelativeMouseState()
+	if c.player != nil {
+		if frame, ok := c.player.Next(); ok {
+			return frame
+		}
+		c.player = nil
+	}
 
+	now := time.Now()
+	var elapsed time.Duration
+	if !c.lastUpdate.IsZero() {
+		elapsed = min(MaxUpdateGap, now.Sub(c.lastUpdate))
 	}
+	c.lastUpdate = now
+	return input.Frame{
+		Elapsed: elapsed,
+		State:   c.input.Sample(),
+		MouseX:  dx,
+		MouseY:  dy,
+	}
+}
+
+// processInput moves the active viewport's camera
+func (c *Controller) processInput(state input.State, dt time.Duration) {
+	v := c.viewports[c.active]
+	seconds := dt.Seconds()
+	v.drive(DirectionCdMoveUp, DirectionCdMoveDown, state.Axis(input.MoveUp, input.MoveDown), seconds)
+	v.drive(DirectionCdStrafeRight, DirectionCdStrafeLeft, state.Axis(input.StrafeRight, input.StrafeLeft), seconds)
+	v.drive(DirectionCdForward, DirectionCdBackward, state.Axis(input.Forward, input.Backward), seconds)
+	v.drive(DirectionCdAntiClockwise, DirectionCdClockwise, state.Axis(input.TurnLeft, input.TurnRight), seconds)
+	v.drive(DirectionCdLookUp, DirectionCdLookDown, state.Axis(input.LookUp, input.LookDown), seconds)
 }
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package controller
+
+import (
+	"math"
+
+	"g3-engine/shapes"
+)
+
+const (
+	MinOrbitDistance = 0.5
+	ZoomRate         = 1.5 // zooms by e^ZoomRate per second
+	PanRate          = 1   // pans the orbit distance per second
+)
+
+// Orbit circles the camera around a target, always facing it from the same distance
+type Orbit struct {
+	camera      *Camera
+	target      *shapes.Vector
+	distance    float64
+	orientation *shapes.Quaternion
+}
+
+// newOrbit starts an orbit from where the camera is, turned to face the target
+func newOrbit(camera *Camera, target *shapes.Vector) *Orbit {
+	d := target.Subtract(camera.camera)
+	distance := d.Length()
+	if distance < MinOrbitDistance {
+		return &Orbit{
+			camera:      camera,
+			target:      target,
+			distance:    MinOrbitDistance,
+			orientation: shapes.CameraOrientation(camera.yaw, camera.pitch, camera.roll),
+		}
+	}
+	d = d.Normalize()
+	return &Orbit{
+		camera:      camera,
+		target:      target,
+		distance:    distance,
+		orientation: shapes.CameraOrientation(math.Atan2(-d.X, d.Z), math.Asin(d.Y), 0),
+	}
+}
+
+// basis returns the orbiting camera's forward, right and up directions, as Viewport.basis does
+func (o *Orbit) basis() (*shapes.Vector, *shapes.Vector, *shapes.Vector) {
+	return shapes.ViewBasis(o.camera.up, o.camera.lookDir, o.orientation)
+}
+
+func (o *Orbit) position() *shapes.Vector {
+	return o.target.Subtract(o.orientation.Rotate(o.camera.lookDir).Multiply(o.distance))
+}
+
+// rotate turns the camera around the target like a trackball, by pitch about the camera's own
+// x-axis and yaw about its own y-axis, so dragging over the top never locks up
+func (o *Orbit) rotate(pitch, yaw float64) {
+	axis := shapes.NewVector(pitch, yaw, 0)
+	if angle := axis.Length(); angle > 0 {
+		o.orientation = shapes.QuaternionAxisAngle(axis, angle).Multiply(o.orientation).Normalize()
+	}
+}
+
+func (o *Orbit) zoom(amount float64) {
+	o.distance = max(MinOrbitDistance, o.distance*math.Exp(-amount))
+}
+
+func (o *Orbit) pan(x, y float64) {
+	_, right, up := o.basis()
+	o.target = o.target.Add(right.Multiply(x * o.distance)).Subtract(up.Multiply(y * o.distance))
+}
+
+// leave puts the free-fly camera where the orbit left it, facing the same way without roll
+func (o *Orbit) leave() {
+	forward, _, _ := o.basis()
+	o.camera.camera = o.position()
+	o.camera.yaw = math.Atan2(-forward.X, forward.Z)
+	o.camera.pitch = min(MaxPitch, max(-MaxPitch, math.Asin(min(1, max(-1, forward.Y)))))
+	o.camera.roll = 0
+}
+
+// toggleOrbit switches between flying freely and orbiting the target. Orthographic views
+// don't orbit, so it does nothing in one.
+func (v *Viewport) toggleOrbit(target *shapes.Vector) {
+	if v.ortho != nil {
+		return
+	}
+	if v.orbit != nil {
+		v.orbit.leave()
+		v.orbit = nil
+		return
+	}
+	v.orbit = newOrbit(v.camera, target)
+}
+
+// bounds returns the corners of the box around every shape
+func (c *Controller) bounds() (*shapes.Vector, *shapes.Vector) {
+	if len(c.shapes) == 0 {
+		return shapes.NewVector(0, 0, 0), shapes.NewVector(0, 0, 0)
+	}
+	lo, hi := c.shapes[0].Bounds()
+	for _, shape := range c.shapes[1:] {
+		l, h := shape.Bounds()
+		lo = shapes.NewVector(min(lo.X, l.X), min(lo.Y, l.Y), min(lo.Z, l.Z))
+		hi = shapes.NewVector(max(hi.X, h.X), max(hi.Y, h.Y), max(hi.Z, h.Z))
+	}
+	return lo, hi
+}
+
+// target is the center of the box around every shape
+func (c *Controller) target() *shapes.Vector {
+	lo, hi := c.bounds()
+	return lo.Add(hi).Divide(2)
+}
+
+// moveOrbit is move while orbiting: forward and backward zoom, strafing and moving up and down
+// pan the target, and turning and looking circle around it
+func (v *Viewport) moveOrbit(dir DirectionCd, seconds float64) {
+	turn := v.speeds.turn * seconds
+	switch dir {
+	case DirectionCdForward:
+		v.orbit.zoom(ZoomRate * seconds)
+	case DirectionCdBackward:
+		v.orbit.zoom(-ZoomRate * seconds)
+	case DirectionCdStrafeLeft:
+		v.orbit.pan(-PanRate*seconds, 0)
+	case DirectionCdStrafeRight:
+		v.orbit.pan(PanRate*seconds, 0)
+	case DirectionCdMoveUp:
+		v.orbit.pan(0, PanRate*seconds)
+	case DirectionCdMoveDown:
+		v.orbit.pan(0, -PanRate*seconds)
+	case DirectionCdLookUp:
+		v.orbit.rotate(-turn, 0)
+	case DirectionCdLookDown:
+		v.orbit.rotate(turn, 0)
+	case DirectionCdAntiClockwise:
+		v.orbit.rotate(0, turn)
+	case DirectionCdClockwise:
+		v.orbit.rotate(0, -turn)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package controller
+
+import (
+	"math"
+
+	"g3-engine/shapes"
+)
+
+type ProjectionCd int
+
+const (
+	ProjectionCdPerspective ProjectionCd = iota
+	ProjectionCdTop
+	ProjectionCdFront
+	ProjectionCdSide
+)
+
+const (
+	OrthoDistance = 500 // how far back an orthographic camera sits from its target
+	MinOrthoSize  = 0.1
+	FitMargin     = 1.1 // room left around shapes when fitting them into an orthographic view
+)
+
+// Ortho looks straight down one axis at a target, without perspective
+type Ortho struct {
+	camera *Camera
+	view   ProjectionCd
+	target *shapes.Vector
+	size   float64 // half the height of the visible area
+}
+
+func newOrtho(camera *Camera, view ProjectionCd, target *shapes.Vector) *Ortho {
+	return &Ortho{
+		camera: camera,
+		view:   view,
+		target: target,
+		size:   MinOrthoSize,
+	}
+}
+
+// orientation turns the camera to look down, along +Z, or along +X
+func (o *Ortho) orientation() *shapes.Quaternion {
+	switch o.view {
+	case ProjectionCdTop:
+		return shapes.CameraOrientation(0, math.Pi/2, 0)
+	case ProjectionCdSide:
+		return shapes.CameraOrientation(-math.Pi/2, 0, 0)
+	}
+	return shapes.IdentityQuaternion()
+}
+
+func (o *Ortho) basis() (*shapes.Vector, *shapes.Vector, *shapes.Vector) {
+	return shapes.ViewBasis(o.camera.up, o.camera.lookDir, o.orientation())
+}
+
+func (o *Ortho) position() *shapes.Vector {
+	forward, _, _ := o.basis()
+	return o.target.Subtract(forward.Multiply(OrthoDistance))
+}
+
+// projection matches the horizontal stretch of the perspective projection for the same aspect
+func (o *Ortho) projection(aspect, near, far float64) *shapes.Matrix4X4 {
+	return shapes.Orthographic(-o.size/aspect, o.size/aspect, -o.size, o.size, near, far)
+}
+
+// fit centers the view on a box and zooms to show all of it
+func (o *Ortho) fit(lo, hi *shapes.Vector, aspect float64) {
+	size := hi.Subtract(lo).Divide(2)
+	across, down := size.X, size.Z
+	switch o.view {
+	case ProjectionCdFront:
+		down = size.Y
+	case ProjectionCdSide:
+		across, down = size.Z, size.Y
+	}
+	o.target = lo.Add(hi).Divide(2)
+	o.size = max(MinOrthoSize, max(down, across*aspect)*FitMargin)
+}
+
+func (o *Ortho) zoom(amount float64) {
+	o.size = max(MinOrthoSize, o.size*math.Exp(-amount))
+}
+
+func (o *Ortho) pan(x, y float64) {
+	_, right, up := o.basis()
+	o.target = o.target.Add(right.Multiply(x * o.size)).Subtract(up.Multiply(y * o.size))
+}
+
+// cycleProjection steps from perspective through the top, front and side orthographic views.
+// The first orthographic view fits the box from lo to hi, and the rest keep its pan and zoom.
+func (v *Viewport) cycleProjection(lo, hi *shapes.Vector) {
+	switch {
+	case v.ortho == nil:
+		v.ortho = newOrtho(v.camera, ProjectionCdTop, lo.Add(hi).Divide(2))
+		v.ortho.fit(lo, hi, v.fov.aspect)
+	case v.ortho.view == ProjectionCdSide:
+		v.ortho = nil
+	default:
+		v.ortho.view++
+	}
+}
+
+// moveOrtho is move in an orthographic view: forward and backward zoom, and strafing and
+// moving up and down pan. Turning and looking do nothing.
+func (v *Viewport) moveOrtho(dir DirectionCd, seconds float64) {
+	switch dir {
+	case DirectionCdForward:
+		v.ortho.zoom(ZoomRate * seconds)
+	case DirectionCdBackward:
+		v.ortho.zoom(-ZoomRate * seconds)
+	case DirectionCdStrafeLeft:
+		v.ortho.pan(-PanRate*seconds, 0)
+	case DirectionCdStrafeRight:
+		v.ortho.pan(PanRate*seconds, 0)
+	case DirectionCdMoveUp:
+		v.ortho.pan(0, PanRate*seconds)
+	case DirectionCdMoveDown:
+		v.ortho.pan(0, -PanRate*seconds)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package controller
+
+import (
+	"fmt"
+	"time"
+)
+
+// FixedStep feeds elapsed frame time to simulation code in whole, fixed size steps,
+// carrying any remainder over to the next frame
+type FixedStep struct {
+	step        time.Duration
+	maxSteps    int // caps catch-up after a stall so it can't spiral
+	accumulator time.Duration
+}
+
+// NewFixedStep panics unless step is positive, since no number of empty steps ever catches up
+func NewFixedStep(step time.Duration) *FixedStep {
+	if step <= 0 {
+		panic(fmt.Sprintf("fixed step must be positive, not %v", step))
+	}
+	return &FixedStep{
+		step:     step,
+		maxSteps: 8,
+	}
+}
+
+func (f *FixedStep) Step() time.Duration {
+	return f.step
+}
+
+// Advance calls update once for each whole step now accumulated, returning how far into
+// the next step the remainder is, from 0 to 1, for interpolating between states
+func (f *FixedStep) Advance(elapsed time.Duration, update func(dt time.Duration)) float64 {
+	f.accumulator += elapsed
+	for steps := 0; f.accumulator >= f.step; steps++ {
+		if steps == f.maxSteps {
+			f.accumulator = 0
+			break
+		}
+		update(f.step)
+		f.accumulator -= f.step
+	}
+	return float64(f.accumulator) / float64(f.step)
+}
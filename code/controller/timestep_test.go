@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package controller
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFixedStepAdvance(t *testing.T) {
+	const step = 10 * time.Millisecond
+	type frame struct {
+		elapsed time.Duration
+		updates int
+		alpha   float64
+	}
+	tests := []struct {
+		name   string
+		frames []frame
+	}{
+		{"exact", []frame{{10 * time.Millisecond, 1, 0}}},
+		{"short", []frame{{4 * time.Millisecond, 0, 0.4}}},
+		{"carry over", []frame{{15 * time.Millisecond, 1, 0.5}, {15 * time.Millisecond, 2, 0}}},
+		{"builds up", []frame{{3 * time.Millisecond, 0, 0.3}, {3 * time.Millisecond, 0, 0.6}, {5 * time.Millisecond, 1, 0.1}}},
+		{"at the cap", []frame{{80 * time.Millisecond, 8, 0}}},
+		{"clamped", []frame{{200 * time.Millisecond, 8, 0}, {5 * time.Millisecond, 0, 0.5}}},
+		{"no time", []frame{{0, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFixedStep(step)
+			for i, fr := range tt.frames {
+				updates := 0
+				alpha := f.Advance(fr.elapsed, func(dt time.Duration) {
+					if dt != step {
+						t.Errorf("frame %d: got dt %v, expected %v", i, dt, step)
+					}
+					updates++
+				})
+				if updates != fr.updates {
+					t.Errorf("frame %d: got %d updates, expected %d", i, updates, fr.updates)
+				}
+				if math.Abs(alpha-fr.alpha) > 1e-9 {
+					t.Errorf("frame %d: got alpha %v, expected %v", i, alpha, fr.alpha)
+				}
+			}
+		})
+	}
+}
+
+func TestNewFixedStepRejectsNonPositive(t *testing.T) {
+	for _, step := range []time.Duration{0, -time.Millisecond} {
+		t.Run(step.String(), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected NewFixedStep(%v) to panic", step)
+				}
+			}()
+			NewFixedStep(step)
+		})
+	}
+}
+
+func TestFixedTimestepOption(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  time.Duration
+		fixed bool
+	}{
+		{"positive", 10 * time.Millisecond, true},
+		{"zero", 0, false},
+		{"negative", -time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{fixedStep: NewFixedStep(time.Second)}
+			FixedTimestep(tt.step)(c)
+			if (c.fixedStep != nil) != tt.fixed {
+				t.Errorf("got fixed step %v, expected one %v", c.fixedStep, tt.fixed)
+			}
+		})
+	}
+}
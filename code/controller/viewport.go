@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package controller
+
+import (
+	"fmt"
+	"math"
+
+	"g3-engine/code/input"
+	"g3-engine/shapes"
+
+	"github.com/jfigge/guilib/graphics"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type LayoutCd int
+
+const (
+	LayoutCdSingle LayoutCd = iota // one perspective view across the whole screen
+	LayoutCdMap                    // perspective view with a top-down map beside it
+	LayoutCdQuad                   // perspective, top, front and side views in quarters
+)
+
+var layoutNames = map[string]LayoutCd{
+	"single": LayoutCdSingle,
+	"map":    LayoutCdMap,
+	"quad":   LayoutCdQuad,
+}
+
+func ParseLayout(name string) (LayoutCd, error) {
+	if layout, ok := layoutNames[name]; ok {
+		return layout, nil
+	}
+	return 0, fmt.Errorf("unknown layout %q, expected single, map or quad", name)
+}
+
+// Viewport draws the shapes from its own camera into its own rectangle of the screen
+type Viewport struct {
+	rect     sdl.Rect
+	camera   *Camera
+	fov      *Fov
+	speeds   *Speeds
+	orbit    *Orbit // nil when flying freely
+	ortho    *Ortho // nil for perspective
+	renderer shapes.Renderer
+}
+
+func NewViewport(rect sdl.Rect, speeds *Speeds) *Viewport {
+	width, height := float64(rect.W), float64(rect.H)
+	f := FOV * math.Pi / 360
+	fov := &Fov{
+		width:  width,
+		height: height,
+		ndov:   0.1,  //width / 2 * math.Tan(f)
+		fdov:   1000, //ndov * DOV
+		aspect: width / height,
+	}
+	return &Viewport{
+		rect: rect,
+		camera: &Camera{
+			up:          shapes.NewVector(0, 1, 0),
+			camera:      shapes.NewVector(0, 0, 0),
+			lookDir:     shapes.NewVector(0, 0, 1),
+			yaw:         0,
+			perspective: shapes.Projection(fov.aspect, 1/f, fov.ndov, fov.fdov),
+		},
+		fov:    fov,
+		speeds: speeds,
+	}
+}
+
+// viewports splits the controller's screen, with the perspective view always first
+func (l LayoutCd) viewports(c *Controller) []*Viewport {
+	w, h := int32(c.width), int32(c.height)
+	switch l {
+	case LayoutCdMap:
+		return []*Viewport{
+			NewViewport(sdl.Rect{W: w, H: h}, c.speeds),
+			c.orthoViewport(sdl.Rect{X: w + 1, W: w, H: h}, ProjectionCdTop),
+		}
+	case LayoutCdQuad:
+		hh := (h - 1) / 2
+		return []*Viewport{
+			NewViewport(sdl.Rect{W: w, H: hh}, c.speeds),
+			c.orthoViewport(sdl.Rect{X: w + 1, W: w, H: hh}, ProjectionCdTop),
+			c.orthoViewport(sdl.Rect{Y: hh + 1, W: w, H: hh}, ProjectionCdFront),
+			c.orthoViewport(sdl.Rect{X: w + 1, Y: hh + 1, W: w, H: hh}, ProjectionCdSide),
+		}
+	}
+	return []*Viewport{
+		NewViewport(sdl.Rect{W: w*2 + 1, H: h}, c.speeds),
+	}
+}
+
+// orthoViewport is a viewport fixed on an orthographic view that fits every shape
+func (c *Controller) orthoViewport(rect sdl.Rect, view ProjectionCd) *Viewport {
+	v := NewViewport(rect, c.speeds)
+	lo, hi := c.bounds()
+	v.ortho = newOrtho(v.camera, view, lo.Add(hi).Divide(2))
+	v.ortho.fit(lo, hi, v.fov.aspect)
+	return v
+}
+
+func (v *Viewport) draw(ss []*shapes.Shape, lighting *shapes.Lighting) {
+	rc := shapes.NewRenderContext(v.projection(), v.fov.width, v.fov.height, v.fov.ndov)
+	rc.View, rc.Lighting = v.view(), lighting
+	shade := rc.Shade()
+	v.renderer.Begin()
+	for _, shape := range ss {
+		ts := shape.GetTriangles(
+			shapes.WorldMatrices(rc.View),
+			v.cull(),
+			rc.ClipNear(),
+			rc.Project(),
+			rc.Center(),
+			rc.ClipScreen(),
+			shade,
+		)
+		shapes.Draw(v.renderer, ts)
+	}
+	graphics.ErrorTrap(v.renderer.End())
+}
+
+// view looks through the orthographic camera in an orthographic view, the orbit camera while
+// orbiting, or else the free-fly camera
+func (v *Viewport) view() *shapes.Matrix4X4 {
+	if v.ortho != nil {
+		return shapes.ViewMatrix(v.ortho.position(), v.ortho.orientation())
+	}
+	if v.orbit != nil {
+		return shapes.ViewMatrix(v.orbit.position(), v.orbit.orientation)
+	}
+	return shapes.CameraMatrix(v.camera.up, v.camera.camera, v.camera.lookDir, v.camera.yaw, v.camera.pitch, v.camera.roll)
+}
+
+// cull drops faces turned away from the camera, which looks along +Z in view space. In an
+// orthographic view every face is seen along that same direction.
+func (v *Viewport) cull() shapes.Transformations {
+	if v.ortho != nil {
+		return shapes.NormalParallel(shapes.NewVector(0, 0, 1))
+	}
+	return shapes.Normal(shapes.NewVector(0, 0, 0))
+}
+
+func (v *Viewport) projection() *shapes.Matrix4X4 {
+	if v.ortho != nil {
+		return v.ortho.projection(v.fov.aspect, v.fov.ndov, v.fov.fdov)
+	}
+	return v.camera.perspective
+}
+
+// drive moves towards positive or negative depending on the sign of axis, scaled by its size
+func (v *Viewport) drive(positive, negative DirectionCd, axis, seconds float64) {
+	if axis > 0 {
+		v.move(positive, axis*seconds)
+	} else if axis < 0 {
+		v.move(negative, -axis*seconds)
+	}
+}
+
+// processMouse turns the camera by the mouse movement since the last update, when look is set.
+// While orbiting, the mouse only rotates or pans when dragged, and in orthographic views it
+// only pans, so both also work with the cursor released.
+func (v *Viewport) processMouse(state input.State, dx, dy int32, look bool) {
+	if v.ortho != nil {
+		if state[input.OrbitRotate] > 0 || state[input.OrbitPan] > 0 {
+			v.ortho.pan(-float64(dx)*MouseSensitivity, float64(dy)*MouseSensitivity)
+		}
+		return
+	}
+	if v.orbit != nil {
+		if state[input.OrbitRotate] > 0 {
+			v.orbit.rotate(float64(dy)*MouseSensitivity, -float64(dx)*MouseSensitivity)
+		} else if state[input.OrbitPan] > 0 {
+			v.orbit.pan(-float64(dx)*MouseSensitivity, float64(dy)*MouseSensitivity)
+		}
+		return
+	}
+	if !look {
+		return
+	}
+	v.camera.yaw -= float64(dx) * MouseSensitivity
+	v.look(float64(dy) * MouseSensitivity)
+}
+
+func (v *Viewport) look(pitch float64) {
+	v.camera.pitch = min(MaxPitch, max(-MaxPitch, v.camera.pitch+pitch))
+}
+
+// basis returns the camera's forward, right and up directions in world space. Up matches the
+// up vector given to LookAt, so on screen it points down.
+func (v *Viewport) basis() (*shapes.Vector, *shapes.Vector, *shapes.Vector) {
+	orientation := shapes.CameraOrientation(v.camera.yaw, v.camera.pitch, v.camera.roll)
+	return shapes.ViewBasis(v.camera.up, v.camera.lookDir, orientation)
+}
+
+// move covers the given number of seconds of full speed motion in the given direction
+func (v *Viewport) move(dir DirectionCd, seconds float64) {
+	if v.ortho != nil {
+		v.moveOrtho(dir, seconds)
+		return
+	}
+	if v.orbit != nil {
+		v.moveOrbit(dir, seconds)
+		return
+	}
+	step := v.speeds.move * seconds
+	turn := v.speeds.turn * seconds
+	forward, right, up := v.basis()
+	switch dir {
+	case DirectionCdForward:
+		v.camera.camera = v.camera.camera.Add(forward.Multiply(step))
+	case DirectionCdBackward:
+		v.camera.camera = v.camera.camera.Subtract(forward.Multiply(step))
+	case DirectionCdStrafeLeft:
+		v.camera.camera = v.camera.camera.Subtract(right.Multiply(step))
+	case DirectionCdStrafeRight:
+		v.camera.camera = v.camera.camera.Add(right.Multiply(step))
+	case DirectionCdMoveUp:
+		v.camera.camera = v.camera.camera.Subtract(up.Multiply(step))
+	case DirectionCdMoveDown:
+		v.camera.camera = v.camera.camera.Add(up.Multiply(step))
+	case DirectionCdLookUp:
+		v.look(-turn)
+	case DirectionCdLookDown:
+		v.look(turn)
+	case DirectionCdAntiClockwise:
+		v.camera.yaw += turn
+	case DirectionCdClockwise:
+		v.camera.yaw -= turn
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type BindingKind int
+
+const (
+	BindingKindKey BindingKind = iota
+	BindingKindMouse
+	BindingKindAxis
+	BindingKindButton
+)
+
+var mouseButtons = map[string]int{
+	"left":   sdl.BUTTON_LEFT,
+	"middle": sdl.BUTTON_MIDDLE,
+	"right":  sdl.BUTTON_RIGHT,
+	"x1":     sdl.BUTTON_X1,
+	"x2":     sdl.BUTTON_X2,
+}
+
+// Binding is a single physical input. Its text form is kind:name, for example "key:W",
+// "key:PageUp", "mouse:left", "button:a", or "axis:lefty-" for one direction of an axis.
+type Binding struct {
+	Kind BindingKind
+	Code int     // scancode, mouse button, game controller axis or button
+	Sign float64 // direction of an axis that counts as pressed
+}
+
+func ParseBinding(s string) (Binding, error) {
+	kind, name, ok := strings.Cut(s, ":")
+	if !ok || name == "" {
+		return Binding{}, fmt.Errorf("binding %q is not kind:name", s)
+	}
+	switch kind {
+	case "key":
+		code := sdl.GetScancodeFromName(name)
+		if code == sdl.SCANCODE_UNKNOWN {
+			return Binding{}, fmt.Errorf("unknown key %q", name)
+		}
+		return Binding{Kind: BindingKindKey, Code: int(code)}, nil
+	case "mouse":
+		button, ok := mouseButtons[strings.ToLower(name)]
+		if !ok {
+			return Binding{}, fmt.Errorf("unknown mouse button %q", name)
+		}
+		return Binding{Kind: BindingKindMouse, Code: button}, nil
+	case "axis":
+		sign := 1.0
+		if strings.HasSuffix(name, "-") {
+			sign = -1
+		}
+		name = strings.TrimRight(name, "+-")
+		axis := sdl.GameControllerGetAxisFromString(name)
+		if axis == sdl.CONTROLLER_AXIS_INVALID {
+			return Binding{}, fmt.Errorf("unknown gamepad axis %q", name)
+		}
+		return Binding{Kind: BindingKindAxis, Code: int(axis), Sign: sign}, nil
+	case "button":
+		button := sdl.GameControllerGetButtonFromString(name)
+		if button == sdl.CONTROLLER_BUTTON_INVALID {
+			return Binding{}, fmt.Errorf("unknown gamepad button %q", name)
+		}
+		return Binding{Kind: BindingKindButton, Code: int(button)}, nil
+	}
+	return Binding{}, fmt.Errorf("unknown binding kind %q in %q", kind, s)
+}
+
+func MustParseBinding(s string) Binding {
+	b, err := ParseBinding(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (b Binding) String() string {
+	switch b.Kind {
+	case BindingKindKey:
+		return "key:" + sdl.GetScancodeName(sdl.Scancode(b.Code))
+	case BindingKindMouse:
+		for name, button := range mouseButtons {
+			if button == b.Code {
+				return "mouse:" + name
+			}
+		}
+	case BindingKindAxis:
+		sign := "+"
+		if b.Sign < 0 {
+			sign = "-"
+		}
+		return "axis:" + sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(b.Code)) + sign
+	case BindingKindButton:
+		return "button:" + sdl.GameControllerGetStringForButton(sdl.GameControllerButton(b.Code))
+	}
+	return fmt.Sprintf("unknown:%d", b.Code)
+}
+
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Binding) UnmarshalText(text []byte) error {
+	var err error
+	*b, err = ParseBinding(string(text))
+	return err
+}
+
+// Bindings maps each action to the inputs that trigger it
+type Bindings map[Action][]Binding
+
+func DefaultBindings() Bindings {
+	return Bindings{
+		Forward:      {MustParseBinding("key:W"), MustParseBinding("axis:lefty-")},
+		Backward:     {MustParseBinding("key:S"), MustParseBinding("axis:lefty+")},
+		TurnLeft:     {MustParseBinding("key:A"), MustParseBinding("axis:rightx-")},
+		TurnRight:    {MustParseBinding("key:D"), MustParseBinding("axis:rightx+")},
+		StrafeLeft:   {MustParseBinding("key:Left"), MustParseBinding("axis:leftx-")},
+		StrafeRight:  {MustParseBinding("key:Right"), MustParseBinding("axis:leftx+")},
+		MoveUp:       {MustParseBinding("key:Up"), MustParseBinding("axis:righttrigger+")},
+		MoveDown:     {MustParseBinding("key:Down"), MustParseBinding("axis:lefttrigger+")},
+		LookUp:       {MustParseBinding("key:PageUp"), MustParseBinding("axis:righty-")},
+		LookDown:     {MustParseBinding("key:PageDown"), MustParseBinding("axis:righty+")},
+		ToggleOrbit:  {MustParseBinding("key:O"), MustParseBinding("button:back")},
+		OrbitRotate:  {MustParseBinding("mouse:left")},
+		OrbitPan:     {MustParseBinding("mouse:right")},
+		Projection:   {MustParseBinding("key:P"), MustParseBinding("button:start")},
+		NextViewport: {MustParseBinding("key:Tab"), MustParseBinding("button:guide")},
+		MouseLook:    {MustParseBinding("key:Escape")},
+	}
+}
+
+// LoadBindings reads a JSON object of action names to lists of bindings. Actions the file
+// leaves out keep their default bindings.
+func LoadBindings(filename string) (Bindings, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var loaded Bindings
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("unable to read bindings from %s: %w", filename, err)
+	}
+
+	bindings := DefaultBindings()
+	for action, bs := range loaded {
+		if !action.valid() {
+			return nil, fmt.Errorf("unknown action %q in %s", action, filename)
+		}
+		bindings[action] = bs
+	}
+	return bindings, nil
+}
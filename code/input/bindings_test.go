@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected Binding
+	}{
+		{"key", "key:W", Binding{Kind: BindingKindKey, Code: sdl.SCANCODE_W}},
+		{"multi-letter key", "key:PageUp", Binding{Kind: BindingKindKey, Code: sdl.SCANCODE_PAGEUP}},
+		{"mouse", "mouse:left", Binding{Kind: BindingKindMouse, Code: sdl.BUTTON_LEFT}},
+		{"mouse any case", "mouse:Right", Binding{Kind: BindingKindMouse, Code: sdl.BUTTON_RIGHT}},
+		{"negative axis", "axis:lefty-", Binding{Kind: BindingKindAxis, Code: sdl.CONTROLLER_AXIS_LEFTY, Sign: -1}},
+		{"positive axis", "axis:rightx+", Binding{Kind: BindingKindAxis, Code: sdl.CONTROLLER_AXIS_RIGHTX, Sign: 1}},
+		{"unsigned axis", "axis:lefttrigger", Binding{Kind: BindingKindAxis, Code: sdl.CONTROLLER_AXIS_TRIGGERLEFT, Sign: 1}},
+		{"button", "button:a", Binding{Kind: BindingKindButton, Code: sdl.CONTROLLER_BUTTON_A}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBinding(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, expected %+v", got, tt.expected)
+			}
+			if again, err := ParseBinding(got.String()); err != nil || again != got {
+				t.Errorf("%q doesn't parse back to %+v", got.String(), got)
+			}
+		})
+	}
+}
+
+func TestParseBindingErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"no kind", "W"},
+		{"no name", "key:"},
+		{"unknown kind", "joystick:a"},
+		{"unknown key", "key:Page Up"},
+		{"unknown mouse button", "mouse:fourth"},
+		{"unknown axis", "axis:sideways-"},
+		{"unknown button", "button:z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b, err := ParseBinding(tt.text); err == nil {
+				t.Errorf("got %+v, expected an error", b)
+			}
+		})
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"override", `{"forward": ["key:Up", "button:a"]}`, false},
+		{"unknown action", `{"jump": ["key:Space"]}`, true},
+		{"bad binding", `{"forward": ["key:Nowhere"]}`, true},
+		{"not json", `forward = key:Up`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "bindings.json")
+			if err := os.WriteFile(filename, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			bindings, err := LoadBindings(filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := []Binding{MustParseBinding("key:Up"), MustParseBinding("button:a")}
+			if got := bindings[Forward]; len(got) != 2 || got[0] != expected[0] || got[1] != expected[1] {
+				t.Errorf("got %v, expected %v", got, expected)
+			}
+			if got := bindings[Backward]; len(got) != len(DefaultBindings()[Backward]) {
+				t.Errorf("got %v, expected the default backward bindings", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"log"
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Gamepad reads the first attached game controller, opening another whenever it's unplugged
+type Gamepad struct {
+	controller *sdl.GameController
+	deadZone   float64 // fraction of an axis' travel that reads as zero
+	curve      float64 // exponent applied past the dead zone, above 1 gives finer control near the center
+}
+
+func NewGamepad(deadZone, curve float64) *Gamepad {
+	return &Gamepad{
+		deadZone: deadZone,
+		curve:    curve,
+	}
+}
+
+// Poll drops a controller that has been unplugged and opens the first one plugged in
+func (g *Gamepad) Poll() {
+	if g.controller != nil {
+		if g.controller.Attached() {
+			return
+		}
+		log.Printf("Gamepad %s disconnected", g.controller.Name())
+		g.controller.Close()
+		g.controller = nil
+	}
+	for i := 0; i < sdl.NumJoysticks(); i++ {
+		if !sdl.IsGameController(i) {
+			continue
+		}
+		if g.controller = sdl.GameControllerOpen(i); g.controller != nil {
+			log.Printf("Gamepad %s connected", g.controller.Name())
+			return
+		}
+	}
+}
+
+func (g *Gamepad) Connected() bool {
+	return g.controller != nil
+}
+
+// Axis returns the position of an axis from -1 to 1 after the dead zone and response curve
+func (g *Gamepad) Axis(axis sdl.GameControllerAxis) float64 {
+	if g.controller == nil {
+		return 0
+	}
+	v := max(-1, float64(g.controller.Axis(axis))/math.MaxInt16)
+	magnitude := math.Abs(v)
+	if magnitude <= g.deadZone {
+		return 0
+	}
+	return math.Copysign(math.Pow((magnitude-g.deadZone)/(1-g.deadZone), g.curve), v)
+}
+
+func (g *Gamepad) Button(button sdl.GameControllerButton) bool {
+	return g.controller != nil && g.controller.Button(button) == 1
+}
+
+func (g *Gamepad) Close() {
+	if g.controller != nil {
+		g.controller.Close()
+		g.controller = nil
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type Action string
+
+const (
+	Forward      Action = "forward"
+	Backward     Action = "backward"
+	TurnLeft     Action = "turn_left"
+	TurnRight    Action = "turn_right"
+	StrafeLeft   Action = "strafe_left"
+	StrafeRight  Action = "strafe_right"
+	MoveUp       Action = "move_up"
+	MoveDown     Action = "move_down"
+	LookUp       Action = "look_up"
+	LookDown     Action = "look_down"
+	ToggleOrbit  Action = "toggle_orbit"
+	OrbitRotate  Action = "orbit_rotate"
+	OrbitPan     Action = "orbit_pan"
+	Projection   Action = "projection"
+	NextViewport Action = "next_viewport"
+	MouseLook    Action = "mouse_look"
+)
+
+var Actions = []Action{
+	Forward, Backward, TurnLeft, TurnRight, StrafeLeft, StrafeRight, MoveUp, MoveDown, LookUp, LookDown,
+	ToggleOrbit, OrbitRotate, OrbitPan, Projection, NextViewport, MouseLook,
+}
+
+func (a Action) valid() bool {
+	for _, action := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// State holds how strongly each action is pressed, from 0 to 1
+type State map[Action]float64
+
+// Axis combines two opposing actions, so pressing both cancels out
+func (s State) Axis(positive, negative Action) float64 {
+	return s[positive] - s[negative]
+}
+
+// Pressed reports whether the action has just been pressed, having been released in previous
+func (s State) Pressed(action Action, previous State) bool {
+	return s[action] > 0 && previous[action] == 0
+}
+
+type Input struct {
+	bindings Bindings
+	gamepad  *Gamepad
+}
+
+func New(bindings Bindings) *Input {
+	return &Input{
+		bindings: bindings,
+	}
+}
+
+// SetGamepad chooses where axis and button bindings are read from, or nil for none
+func (in *Input) SetGamepad(gamepad *Gamepad) {
+	in.gamepad = gamepad
+}
+
+// Sample reads the current state of every pressed action; an action bound to several inputs
+// takes the strongest
+func (in *Input) Sample() State {
+	if in.gamepad != nil {
+		in.gamepad.Poll()
+	}
+	keys := sdl.GetKeyboardState()
+	_, _, buttons := sdl.GetMouseState()
+	state := State{}
+	for action, bs := range in.bindings {
+		for _, b := range bs {
+			if v := in.value(b, keys, buttons); v > state[action] {
+				state[action] = v
+			}
+		}
+	}
+	return state
+}
+
+func (in *Input) value(b Binding, keys []uint8, buttons uint32) float64 {
+	switch b.Kind {
+	case BindingKindKey:
+		if b.Code < len(keys) && keys[b.Code] == 1 {
+			return 1
+		}
+	case BindingKindMouse:
+		if buttons&sdl.Button(uint32(b.Code)) != 0 {
+			return 1
+		}
+	case BindingKindAxis:
+		if in.gamepad != nil {
+			return max(0, in.gamepad.Axis(sdl.GameControllerAxis(b.Code))*b.Sign)
+		}
+	case BindingKindButton:
+		if in.gamepad != nil && in.gamepad.Button(sdl.GameControllerButton(b.Code)) {
+			return 1
+		}
+	}
+	return 0
+}
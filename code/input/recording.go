@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Frame is everything a single update reads from the player
+type Frame struct {
+	Elapsed time.Duration `json:"elapsed"`
+	State   State         `json:"state,omitempty"`
+	MouseX  int32         `json:"mouse_x,omitempty"`
+	MouseY  int32         `json:"mouse_y,omitempty"`
+}
+
+// Settings are the options that change how recorded input plays out, so a replay must use
+// the same ones as its recording
+type Settings struct {
+	Timestep time.Duration `json:"timestep"` // zero when movement follows frame time
+	Layout   string        `json:"layout"`
+	Bindings Bindings      `json:"bindings"`
+}
+
+// mismatch describes the first setting that differs from the recorded one
+func (s Settings) mismatch(recorded Settings) error {
+	switch {
+	case s.Timestep != recorded.Timestep:
+		return fmt.Errorf("recorded with timestep %v, replaying with %v", recorded.Timestep, s.Timestep)
+	case s.Layout != recorded.Layout:
+		return fmt.Errorf("recorded with layout %q, replaying with %q", recorded.Layout, s.Layout)
+	}
+	for _, action := range Actions {
+		if !equalBindings(s.Bindings[action], recorded.Bindings[action]) {
+			return fmt.Errorf("recorded with %s bound to %v, replaying with %v", action, recorded.Bindings[action], s.Bindings[action])
+		}
+	}
+	return nil
+}
+
+func equalBindings(bs1, bs2 []Binding) bool {
+	if len(bs1) != len(bs2) {
+		return false
+	}
+	for i := range bs1 {
+		if bs1[i] != bs2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// header is the first line of a recording
+type header struct {
+	Settings *Settings `json:"settings"`
+}
+
+// Recorder writes a header of settings followed by frames, as JSON one per line
+type Recorder struct {
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+}
+
+func CreateRecorder(filename string, settings Settings) (*Recorder, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	r := &Recorder{
+		file: file,
+		w:    w,
+		enc:  json.NewEncoder(w),
+	}
+	if err = r.enc.Encode(header{Settings: &settings}); err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return r, nil
+}
+
+func (r *Recorder) Record(frame Frame) error {
+	return r.enc.Encode(frame)
+}
+
+func (r *Recorder) Close() error {
+	return errors.Join(r.w.Flush(), r.file.Close())
+}
+
+// Player hands back recorded frames in the order they were recorded
+type Player struct {
+	frames []Frame
+	next   int
+}
+
+// NewPlayer reads a recording, rejecting it unless it was made with the given settings
+func NewPlayer(r io.Reader, settings Settings) (*Player, error) {
+	dec := json.NewDecoder(r)
+	var h header
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("unable to read header: %w", err)
+	}
+	if h.Settings == nil {
+		return nil, errors.New("recording has no settings header")
+	}
+	if err := settings.mismatch(*h.Settings); err != nil {
+		return nil, err
+	}
+
+	p := &Player{}
+	for {
+		var frame Frame
+		if err := dec.Decode(&frame); errors.Is(err, io.EOF) {
+			return p, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read frame %d: %w", len(p.frames)+1, err)
+		}
+		p.frames = append(p.frames, frame)
+	}
+}
+
+func OpenPlayer(filename string, settings Settings) (*Player, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	p, err := NewPlayer(file, settings)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return p, nil
+}
+
+// Next returns the following frame, or false once they've all been played
+func (p *Player) Next() (Frame, bool) {
+	if p.next >= len(p.frames) {
+		return Frame{}, false
+	}
+	p.next++
+	return p.frames[p.next-1], true
+}
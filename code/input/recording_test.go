@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package input
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func recordingSettings() Settings {
+	return Settings{Timestep: 10 * time.Millisecond, Layout: "map", Bindings: DefaultBindings()}
+}
+
+func TestRecordReplay(t *testing.T) {
+	frames := []Frame{
+		{Elapsed: 16 * time.Millisecond},
+		{Elapsed: 17 * time.Millisecond, State: State{Forward: 1, TurnLeft: 0.25}},
+		{Elapsed: 15 * time.Millisecond, State: State{OrbitRotate: 1}, MouseX: -3, MouseY: 7},
+		{Elapsed: 0},
+	}
+	filename := filepath.Join(t.TempDir(), "input.jsonl")
+	recorder, err := CreateRecorder(filename, recordingSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		if err = recorder.Record(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	player, err := OpenPlayer(filename, recordingSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range frames {
+		got, ok := player.Next()
+		if !ok {
+			t.Fatalf("frame %d missing", i)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("frame %d: got %+v, expected %+v", i, got, expected)
+		}
+	}
+	if frame, ok := player.Next(); ok {
+		t.Errorf("got extra frame %+v", frame)
+	}
+}
+
+func TestReplaySettingsMismatch(t *testing.T) {
+	rebound := DefaultBindings()
+	rebound[Forward] = []Binding{MustParseBinding("key:Up")}
+	tests := []struct {
+		name     string
+		settings Settings
+		expected string
+	}{
+		{"timestep", Settings{Layout: "map", Bindings: DefaultBindings()}, "timestep"},
+		{"layout", Settings{Timestep: 10 * time.Millisecond, Layout: "quad", Bindings: DefaultBindings()}, "layout"},
+		{"bindings", Settings{Timestep: 10 * time.Millisecond, Layout: "map", Bindings: rebound}, "forward"},
+	}
+	filename := filepath.Join(t.TempDir(), "input.jsonl")
+	recorder, err := CreateRecorder(filename, recordingSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenPlayer(filename, tt.settings)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("got %v, expected a %s mismatch", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReplayWithoutHeader(t *testing.T) {
+	_, err := NewPlayer(strings.NewReader(`{"elapsed":16000000}`+"\n"), recordingSettings())
+	if err == nil {
+		t.Error("expected a recording without a header to be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package render
+
+import (
+	"g3-engine/shapes"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// SDLRenderer rasterizes in software, with a depth buffer, then copies each finished frame
+// to its rectangle of an SDL renderer through a streaming texture
+type SDLRenderer struct {
+	renderer *sdl.Renderer
+	texture  *sdl.Texture
+	raster   *shapes.Rasterizer
+	dst      sdl.Rect
+}
+
+func NewSDLRenderer(renderer *sdl.Renderer, dst sdl.Rect) (*SDLRenderer, error) {
+	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_RGBA8888, sdl.TEXTUREACCESS_STREAMING, dst.W, dst.H)
+	if err != nil {
+		return nil, err
+	}
+	if err = texture.SetBlendMode(sdl.BLENDMODE_BLEND); err != nil {
+		_ = texture.Destroy()
+		return nil, err
+	}
+	return &SDLRenderer{
+		renderer: renderer,
+		texture:  texture,
+		raster:   shapes.NewRasterizer(int(dst.W), int(dst.H)),
+		dst:      dst,
+	}, nil
+}
+
+func (r *SDLRenderer) Begin() {
+	r.raster.Clear(0)
+}
+
+func (r *SDLRenderer) DrawTriangles(vs []shapes.Vertex) {
+	r.raster.DrawTriangles(vs)
+}
+
+func (r *SDLRenderer) DrawPhong(ts []shapes.PhongTriangle) {
+	r.raster.DrawPhong(ts)
+}
+
+func (r *SDLRenderer) End() error {
+	if err := r.texture.UpdateRGBA(nil, r.raster.Pixels(), r.raster.Width()); err != nil {
+		return err
+	}
+	return r.renderer.Copy(r.texture, nil, &r.dst)
+}
+
+func (r *SDLRenderer) Destroy() {
+	_ = r.texture.Destroy()
+}
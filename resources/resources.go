@@ -0,0 +1,14 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package resources
+
+import (
+	"embed"
+)
+
+// Objects holds the bundled models under objects/
+//
+//go:embed objects
+var Objects embed.FS
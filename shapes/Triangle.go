@@ -5,14 +5,21 @@
 package shapes
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
+	"image/color"
 )
 
 type Triangle struct {
-	vectors [3]*Vector
-	normal  *Vector
-	visible bool
-	color   uint32
+	vectors   [3]*Vector
+	uvs       [3]*Vector // per-vertex texture coordinates, nil when absent
+	normals   [3]*Vector // per-vertex normals, nil when absent
+	positions [3]*Vector // view space positions, kept for lighting once vectors are projected
+	normal    *Vector
+	visible   bool
+	color     uint32
+	material  *Material
+	shading   Shading
+	colors    [3]uint32 // per-vertex lit colors for Gouraud shading
+	shader    Shader    // per-pixel lighting for Phong shading
 }
 
 func NewTriangle(v1, v2, v3 *Vector, color uint32) *Triangle {
@@ -23,44 +30,112 @@ func NewTriangle(v1, v2, v3 *Vector, color uint32) *Triangle {
 	}
 }
 
-func (t *Triangle) GetPoints() []sdl.FPoint {
-	return []sdl.FPoint{
-		{X: float32(t.vectors[0].X), Y: float32(t.vectors[0].Y)},
-		{X: float32(t.vectors[1].X), Y: float32(t.vectors[1].Y)},
-		{X: float32(t.vectors[2].X), Y: float32(t.vectors[2].Y)},
-		{X: float32(t.vectors[0].X), Y: float32(t.vectors[0].Y)},
+func (t *Triangle) duplicate() *Triangle {
+	return &Triangle{
+		vectors:  t.vectors,
+		uvs:      t.uvs,
+		normals:  t.normals,
+		normal:   NewVector(0, 0, 0),
+		color:    t.color,
+		material: t.material,
+		shading:  t.shading,
 	}
 }
 
-func (t *Triangle) GetVertices() []sdl.Vertex {
+// GetVertices colors each vertex with the face color, or with its own lit color for smooth
+// shading. Phong shading can't light every pixel through a Vertex, so it lights the vertices.
+func (t *Triangle) GetVertices() []Vertex {
 	c := t.GetFaceColor()
-	return []sdl.Vertex{
-		{Position: sdl.FPoint{X: float32(t.vectors[0].X), Y: float32(t.vectors[0].Y)}, Color: c, TexCoord: sdl.FPoint{}},
-		{Position: sdl.FPoint{X: float32(t.vectors[1].X), Y: float32(t.vectors[1].Y)}, Color: c, TexCoord: sdl.FPoint{}},
-		{Position: sdl.FPoint{X: float32(t.vectors[2].X), Y: float32(t.vectors[2].Y)}, Color: c, TexCoord: sdl.FPoint{}},
+	vs := make([]Vertex, 3)
+	for i, v := range t.vectors {
+		vs[i] = Vertex{X: v.X, Y: v.Y, Z: v.Z, Color: c}
+		if uv := t.uvs[i]; uv != nil {
+			vs[i].U, vs[i].V = uv.X, uv.Y
+		}
+		switch {
+		case t.shading == ShadingGouraud && t.colors != [3]uint32{}:
+			// Colors are only lit by Shade, so pipelines without it keep the face color
+			vs[i].Color = unpackRGBA(t.colors[i])
+		case t.shader != nil:
+			vs[i].Color = t.shader(t.position(i), t.vertexNormal(i))
+		}
 	}
+	return vs
+}
+
+// phong is the triangle as a PhongTriangle, for renderers that light every pixel
+func (t *Triangle) phong() *PhongTriangle {
+	c := t.GetFaceColor()
+	p := &PhongTriangle{Shader: t.shader}
+	for i, v := range t.vectors {
+		p.Vertices[i] = Vertex{X: v.X, Y: v.Y, Z: v.Z, Color: c}
+		if uv := t.uvs[i]; uv != nil {
+			p.Vertices[i].U, p.Vertices[i].V = uv.X, uv.Y
+		}
+		p.Positions[i] = *t.position(i)
+		p.Normals[i] = *t.vertexNormal(i)
+	}
+	return p
+}
+
+// vertexNormal falls back to the face normal for vertices without their own
+func (t *Triangle) vertexNormal(i int) *Vector {
+	if t.normals[i] != nil {
+		return t.normals[i]
+	}
+	return t.normal
+}
+
+// position falls back to the vertex itself when no view space position was kept
+func (t *Triangle) position(i int) *Vector {
+	if t.positions[i] != nil {
+		return t.positions[i]
+	}
+	return t.vectors[i]
+}
+
+func (t *Triangle) center() *Vector {
+	return t.position(0).Add(t.position(1)).Add(t.position(2)).Divide(3)
+}
 
+func (t *Triangle) GetFaceColor() color.RGBA {
+	return unpackRGBA(t.color)
 }
 
-func (t *Triangle) GetFaceColor() sdl.Color {
-	return sdl.Color{
-		R: uint8(t.color >> 24),
-		G: uint8(t.color >> 16),
-		B: uint8(t.color >> 8),
-		A: uint8(t.color),
+// diffuse is the material's diffuse color when there is one, otherwise the packed color
+func (t *Triangle) diffuse() (RGB, float64) {
+	if t.material != nil {
+		return t.material.Diffuse, t.material.Dissolve
 	}
+	return unpackRGB(t.color)
+}
+
+// Stage is a single step of the triangle pipeline; it may emit any number of triangles
+type Stage interface {
+	Apply(t *Triangle) []*Triangle
 }
 
 type Transformations func(*Triangle) *Triangle
 
+func (f Transformations) Apply(t *Triangle) []*Triangle {
+	return []*Triangle{f(t)}
+}
+
 func (t *Triangle) process(f1, f2, f3 *Vector) *Triangle {
 	if !t.visible {
 		return t
 	}
 	return &Triangle{
-		normal:  t.normal,
-		visible: t.visible,
-		color:   t.color,
+		normal:    t.normal,
+		visible:   t.visible,
+		color:     t.color,
+		material:  t.material,
+		shading:   t.shading,
+		colors:    t.colors,
+		shader:    t.shader,
+		uvs:       t.uvs,
+		normals:   t.normals,
+		positions: t.positions,
 		vectors: [3]*Vector{
 			f1,
 			f2,
@@ -74,90 +149,118 @@ func WorldMatrices(matrices ...*Matrix4X4) Transformations {
 	for _, m := range matrices {
 		m1 = m1.Multiply(m)
 	}
+	normalMatrix := m1.NormalMatrix()
 	return func(t *Triangle) *Triangle {
-		return t.process(
-			t.vectors[0].MatrixMultiply(m1),
-			t.vectors[1].MatrixMultiply(m1),
-			t.vectors[2].MatrixMultiply(m1),
-		)
+		return t.transform(m1, normalMatrix)
 	}
 }
 
-func RotateX(a float64) Transformations {
-	return func(t *Triangle) *Triangle {
-		rotation := RotationX(a)
-		return t.process(
-			t.vectors[0].MatrixMultiply(rotation),
-			t.vectors[1].MatrixMultiply(rotation),
-			t.vectors[2].MatrixMultiply(rotation),
-		)
+// transform moves the vertices by m and the vertex normals by the matching normal matrix
+func (t *Triangle) transform(m, normalMatrix *Matrix4X4) *Triangle {
+	t2 := t.process(
+		t.vectors[0].MatrixMultiply(m),
+		t.vectors[1].MatrixMultiply(m),
+		t.vectors[2].MatrixMultiply(m),
+	)
+	if t2 != t {
+		t2.normals = transformNormals(t.normals, normalMatrix)
 	}
+	return t2
+}
+
+func RotateX(a float64) Transformations {
+	return WorldMatrices(RotationX(a))
 }
 
 func RotateY(a float64) Transformations {
-	return func(t *Triangle) *Triangle {
-		rotation := RotationY(a)
-		return t.process(
-			t.vectors[0].MatrixMultiply(rotation),
-			t.vectors[1].MatrixMultiply(rotation),
-			t.vectors[2].MatrixMultiply(rotation),
-		)
-	}
+	return WorldMatrices(RotationY(a))
 }
 
 func RotateZ(a float64) Transformations {
-	return func(t *Triangle) *Triangle {
-		rotation := RotationZ(a)
-		return t.process(
-			t.vectors[0].MatrixMultiply(rotation),
-			t.vectors[1].MatrixMultiply(rotation),
-			t.vectors[2].MatrixMultiply(rotation),
-		)
-	}
+	return WorldMatrices(RotationZ(a))
 }
 
 func Translate(x, y, z float64) Transformations {
-	return func(t *Triangle) *Triangle {
-		rotation := Translation(x, y, z)
-		return t.process(
-			t.vectors[0].MatrixMultiply(rotation),
-			t.vectors[1].MatrixMultiply(rotation),
-			t.vectors[2].MatrixMultiply(rotation),
-		)
-	}
+	return WorldMatrices(Translation(x, y, z))
 }
 
-func Camera(up, camera, lookDir *Vector, yaw float64) Transformations {
-	return func(t *Triangle) *Triangle {
-		viewMatrix := LookAt(camera, camera.Add(lookDir.MatrixMultiply(RotationY(yaw))), up)
-		return t.process(
-			t.vectors[0].MatrixMultiply(viewMatrix),
-			t.vectors[1].MatrixMultiply(viewMatrix),
-			t.vectors[2].MatrixMultiply(viewMatrix),
-		)
-	}
+func Camera(up, camera, lookDir *Vector, yaw, pitch, roll float64) Transformations {
+	return WorldMatrices(CameraMatrix(up, camera, lookDir, yaw, pitch, roll))
+}
+
+// CameraMatrix is the view matrix the Camera stage applies
+func CameraMatrix(up, camera, lookDir *Vector, yaw, pitch, roll float64) *Matrix4X4 {
+	return viewMatrix(up, camera, lookDir, CameraOrientation(yaw, pitch, roll))
+}
+
+// CameraOrientation rolls about Z, then pitches about X, then yaws about Y
+func CameraOrientation(yaw, pitch, roll float64) *Quaternion {
+	return QuaternionAxisAngle(NewVector(0, 0, 1), roll).
+		Multiply(QuaternionAxisAngle(NewVector(1, 0, 0), pitch)).
+		Multiply(QuaternionAxisAngle(NewVector(0, 1, 0), yaw))
 }
 
+// ViewBasis returns the forward, right and up directions, in world space, of a view that looks
+// along lookDir with up once both are turned by orientation
+func ViewBasis(up, lookDir *Vector, orientation *Quaternion) (*Vector, *Vector, *Vector) {
+	forward := orientation.Rotate(lookDir)
+	u := orientation.Rotate(up)
+	return forward, forward.CrossProduct(u), u
+}
+
+// View looks from camera along +Z, with +Y up, after both are turned by orientation
+func View(camera *Vector, orientation *Quaternion) Transformations {
+	return WorldMatrices(ViewMatrix(camera, orientation))
+}
+
+// ViewMatrix is the view matrix the View stage applies
+func ViewMatrix(camera *Vector, orientation *Quaternion) *Matrix4X4 {
+	return viewMatrix(NewVector(0, 1, 0), camera, NewVector(0, 0, 1), orientation)
+}
+
+func viewMatrix(up, camera, lookDir *Vector, orientation *Quaternion) *Matrix4X4 {
+	return LookAt(camera, camera.Add(orientation.Rotate(lookDir)), orientation.Rotate(up))
+}
+
+// Normal finds the face normal and culls faces turned away from the camera. It runs in view
+// space, so it also keeps the view space positions for Shade.
 func Normal(camera *Vector) Transformations {
+	return normal(func(t *Triangle) *Vector {
+		return t.vectors[0].Subtract(camera)
+	})
+}
+
+// NormalParallel is Normal for orthographic projections, where every face is seen along the
+// same view direction rather than from a point
+func NormalParallel(direction *Vector) Transformations {
+	return normal(func(*Triangle) *Vector {
+		return direction
+	})
+}
+
+// normal culls faces whose normal points back along the line of sight to them
+func normal(sight func(t *Triangle) *Vector) Transformations {
 	return func(t *Triangle) *Triangle {
 		if !t.visible {
 			return t
 		}
+		t.positions = t.vectors
 		t.normal = t.vectors[1].
 			Subtract(t.vectors[0]).
 			CrossProduct(t.vectors[2].Subtract(t.vectors[0])).
 			Normalize()
-		t.visible = t.normal.DotProduct(t.vectors[0].Subtract(camera)) > 0
+		t.visible = t.normal.DotProduct(sight(t)) > 0
 		return t
 	}
 }
 
-func Project() Transformations {
+// Project applies a perspective or orthographic projection, then divides by w
+func Project(projection *Matrix4X4) Transformations {
 	return func(t *Triangle) *Triangle {
 		return t.process(
-			project()(t.vectors[0]),
-			project()(t.vectors[1]),
-			project()(t.vectors[2]),
+			project(projection)(t.vectors[0]),
+			project(projection)(t.vectors[1]),
+			project(projection)(t.vectors[2]),
 		)
 	}
 }
@@ -172,17 +275,28 @@ func Center(x, y float64) Transformations {
 	}
 }
 
-func Shade(light *Vector) Transformations {
+// Shade lights the face, and for smooth shading its vertices or pixels, with lighting that
+// is already in view space
+func Shade(lighting *Lighting) Transformations {
 	return func(t *Triangle) *Triangle {
-		dp := max(0.1, t.normal.DotProduct(light.Normalize()))
-		a := uint8(t.color)
-		t.color >>= 8
-		b := uint8(float64(uint8(t.color)) * dp)
-		t.color >>= 8
-		g := uint8(float64(uint8(t.color)) * dp)
-		t.color >>= 8
-		r := uint8(float64(uint8(t.color)) * dp)
-		t.color = sdl.Color{R: r, G: g, B: b, A: a}.Uint32()
+		if !t.visible {
+			return t
+		}
+		diffuse, alpha := t.diffuse()
+		lit := func(position, normal *Vector) uint32 {
+			return lighting.illuminate(position, normal).Multiply(diffuse).pack(alpha)
+		}
+		t.color = lit(t.center(), t.normal)
+		switch t.shading {
+		case ShadingGouraud:
+			for i := range t.colors {
+				t.colors[i] = lit(t.position(i), t.vertexNormal(i))
+			}
+		case ShadingPhong:
+			t.shader = func(position, normal *Vector) color.RGBA {
+				return unpackRGBA(lit(position, normal.Normalize()))
+			}
+		}
 		return t
 	}
 }
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"testing"
+)
+
+func TestNormalCulling(t *testing.T) {
+	// A face whose normal, (0, -1, 1), leans away from the view direction. A perspective camera
+	// at the origin sees it from in front when it's ahead, but from behind when it's well
+	// above, while a parallel view sees it the same way wherever it is.
+	tilted := func(x, y, z float64) *Triangle {
+		return NewTriangle(NewVector(x, y, z), NewVector(x+1, y, z), NewVector(x, y+1, z+1), 0xFFFFFFFF)
+	}
+	tests := []struct {
+		name     string
+		triangle *Triangle
+		stage    Transformations
+		visible  bool
+	}{
+		{"perspective ahead", tilted(0, 0, 5), Normal(NewVector(0, 0, 0)), true},
+		{"perspective above", tilted(0, 10, 5), Normal(NewVector(0, 0, 0)), false},
+		{"parallel ahead", tilted(0, 0, 5), NormalParallel(NewVector(0, 0, 1)), true},
+		{"parallel above", tilted(0, 10, 5), NormalParallel(NewVector(0, 0, 1)), true},
+		{"parallel reversed", tilted(0, 10, 5), NormalParallel(NewVector(0, 0, -1)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.triangle.visible = true
+			if got := tt.stage(tt.triangle).visible; got != tt.visible {
+				t.Errorf("got visible %v, expected %v", got, tt.visible)
+			}
+		})
+	}
+}
+
+func TestGouraudVertexColors(t *testing.T) {
+	face := uint32(0x336699FF)
+	tests := []struct {
+		name     string
+		shade    bool
+		expected [3]uint32
+	}{
+		{"unshaded", false, [3]uint32{face, face, face}},
+		{"shaded", true, [3]uint32{0x000000FF, 0x000000FF, 0x000000FF}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTriangle(NewVector(0, 0, 5), NewVector(1, 0, 5), NewVector(0, 1, 5), face)
+			tr.visible, tr.shading = true, ShadingGouraud
+			tr = Normal(NewVector(0, 0, 0))(tr)
+			if tt.shade {
+				tr = Shade(NewLighting(RGB{}))(tr)
+			}
+			for i, v := range tr.GetVertices() {
+				if got := packRGBA(v.Color); got != tt.expected[i] {
+					t.Errorf("vertex %d: got %08X, expected %08X", i, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStagesMatchWorldMatrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		stage    Transformations
+		expected *Matrix4X4
+	}{
+		{"rotate x", RotateX(0.7), RotationX(0.7)},
+		{"rotate y", RotateY(-1.2), RotationY(-1.2)},
+		{"rotate z", RotateZ(2.5), RotationZ(2.5)},
+		{"translate", Translate(3, -4, 5), Translation(3, -4, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTriangle(NewVector(1, 2, 3), NewVector(-2, 0, 1), NewVector(0, -1, 4), 0xFFFFFFFF)
+			tr.visible = true
+			tr.normals = [3]*Vector{NewVectorW(0, 0, 1, 0), NewVectorW(0.6, 0.8, 0, 0), NewVectorW(-1, 0, 0, 0)}
+			got, expected := tt.stage(tr), WorldMatrices(tt.expected)(tr)
+			for i := range got.vectors {
+				if !vectorNear(got.vectors[i], expected.vectors[i]) {
+					t.Errorf("vertex %d: got %v, expected %v", i, *got.vectors[i], *expected.vectors[i])
+				}
+				if !vectorNear(got.normals[i], expected.normals[i]) {
+					t.Errorf("normal %d: got %v, expected %v", i, *got.normals[i], *expected.normals[i])
+				}
+			}
+		})
+	}
+}
@@ -37,12 +37,12 @@ func center(x, y float64) VectorTransformations {
 	}
 }
 
-func project() VectorTransformations {
+func project(projection *Matrix4X4) VectorTransformations {
 	return func(v *Vector) *Vector {
-		if projectionMatrix == nil {
+		if projection == nil {
 			return &Vector{X: v.X, Y: v.Y, Z: v.Z}
 		}
-		projected := v.MatrixMultiply(projectionMatrix)
+		projected := v.MatrixMultiply(projection)
 		if projected.W == 0 {
 			return projected
 		}
@@ -118,6 +118,15 @@ func (v *Vector) Divide(l float64) *Vector {
 	}
 }
 
+func (v *Vector) Lerp(v1 *Vector, t float64) *Vector {
+	return &Vector{
+		X: v.X + (v1.X-v.X)*t,
+		Y: v.Y + (v1.Y-v.Y)*t,
+		Z: v.Z + (v1.Z-v.Z)*t,
+		W: v.W + (v1.W-v.W)*t,
+	}
+}
+
 func (v *Vector) MatrixMultiply(matrix *Matrix4X4) *Vector {
 	return &Vector{
 		X: v.X*matrix[0][0] + v.Y*matrix[1][0] + v.Z*matrix[2][0] + v.W*matrix[3][0],
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+type Clippings func(*Triangle) []*Triangle
+
+func (f Clippings) Apply(t *Triangle) []*Triangle {
+	return f(t)
+}
+
+// ClipNear clips view space triangles against the near plane, z = near
+func ClipNear(near float64) Clippings {
+	point := NewVector(0, 0, near)
+	normal := NewVector(0, 0, 1)
+	return func(t *Triangle) []*Triangle {
+		return clip(t, point, normal)
+	}
+}
+
+// ClipScreen clips screen space triangles against the edges of a width x height viewport
+func ClipScreen(width, height float64) Clippings {
+	planes := [4][2]*Vector{
+		{NewVector(0, 0, 0), NewVector(1, 0, 0)},
+		{NewVector(0, 0, 0), NewVector(0, 1, 0)},
+		{NewVector(width, 0, 0), NewVector(-1, 0, 0)},
+		{NewVector(0, height, 0), NewVector(0, -1, 0)},
+	}
+	return func(t *Triangle) []*Triangle {
+		ts := []*Triangle{t}
+		for _, plane := range planes {
+			var next []*Triangle
+			for _, t1 := range ts {
+				next = append(next, clip(t1, plane[0], plane[1])...)
+			}
+			ts = next
+		}
+		return ts
+	}
+}
+
+// clip keeps the part of the triangle on the side of the plane the normal points to,
+// returning zero, one or two triangles with the original winding
+func clip(t *Triangle, point, normal *Vector) []*Triangle {
+	if !t.visible {
+		return []*Triangle{t}
+	}
+
+	d := normal.DotProduct(point)
+	var dists [3]float64
+	inside := 0
+	for i, v := range t.vectors {
+		dists[i] = normal.DotProduct(v) - d
+		if dists[i] >= 0 {
+			inside++
+		}
+	}
+	switch inside {
+	case 0:
+		return nil
+	case 3:
+		return []*Triangle{t}
+	}
+
+	poly := make([]*Vector, 0, 4)
+	uvs := make([]*Vector, 0, 4)
+	nms := make([]*Vector, 0, 4)
+	pos := make([]*Vector, 0, 4)
+	for i := 0; i < 3; i++ {
+		j := (i + 1) % 3
+		if dists[i] >= 0 {
+			poly = append(poly, t.vectors[i])
+			uvs = append(uvs, t.uvs[i])
+			nms = append(nms, t.normals[i])
+			pos = append(pos, t.positions[i])
+		}
+		// A vertex on the plane is kept as it is, rather than also starting a crossing there
+		if dists[i] > 0 && dists[j] < 0 || dists[i] < 0 && dists[j] > 0 {
+			f := dists[i] / (dists[i] - dists[j])
+			poly = append(poly, t.vectors[i].Lerp(t.vectors[j], f))
+			uvs = append(uvs, lerpAttribute(t.uvs[i], t.uvs[j], f))
+			nms = append(nms, lerpAttribute(t.normals[i], t.normals[j], f))
+			pos = append(pos, lerpAttribute(t.positions[i], t.positions[j], f))
+		}
+	}
+
+	ts := make([]*Triangle, 0, 2)
+	for i := 1; i+1 < len(poly); i++ {
+		t2 := t.process(poly[0], poly[i], poly[i+1])
+		t2.uvs = [3]*Vector{uvs[0], uvs[i], uvs[i+1]}
+		t2.normals = [3]*Vector{nms[0], nms[i], nms[i+1]}
+		t2.positions = [3]*Vector{pos[0], pos[i], pos[i+1]}
+		ts = append(ts, t2)
+	}
+	return ts
+}
+
+func lerpAttribute(v, v1 *Vector, f float64) *Vector {
+	if v == nil || v1 == nil {
+		return nil
+	}
+	return v.Lerp(v1, f)
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+func clipTriangle(v0, v1, v2 *Vector) *Triangle {
+	t := NewTriangle(v0, v1, v2, 0xFFFFFFFF)
+	t.visible = true
+	return t
+}
+
+// faceNormal is the unnormalized normal the winding of a triangle gives it
+func faceNormal(t *Triangle) *Vector {
+	return t.vectors[1].Subtract(t.vectors[0]).CrossProduct(t.vectors[2].Subtract(t.vectors[0]))
+}
+
+func TestClip(t *testing.T) {
+	tests := []struct {
+		name     string
+		clip     Clippings
+		triangle *Triangle
+		expected [][3]*Vector
+	}{
+		{
+			"near, all inside", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 2), NewVector(1, 0, 2), NewVector(0, 1, 2)),
+			[][3]*Vector{{NewVector(0, 0, 2), NewVector(1, 0, 2), NewVector(0, 1, 2)}},
+		},
+		{
+			"near, none inside", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 0.5), NewVector(1, 0, 0.5), NewVector(0, 1, -2)),
+			nil,
+		},
+		{
+			"near, one inside", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 2), NewVector(4, 0, -2), NewVector(0, 4, -2)),
+			[][3]*Vector{{NewVector(0, 0, 2), NewVector(1, 0, 1), NewVector(0, 1, 1)}},
+		},
+		{
+			"near, two inside", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 3), NewVector(2, 0, 3), NewVector(0, 2, -1)),
+			[][3]*Vector{
+				{NewVector(0, 0, 3), NewVector(2, 0, 3), NewVector(1, 1, 1)},
+				{NewVector(0, 0, 3), NewVector(1, 1, 1), NewVector(0, 1, 1)},
+			},
+		},
+		{
+			"near, on the plane", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 1), NewVector(1, 0, 1), NewVector(0, 1, 1)),
+			[][3]*Vector{{NewVector(0, 0, 1), NewVector(1, 0, 1), NewVector(0, 1, 1)}},
+		},
+		{
+			"near, touching at a vertex", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 1), NewVector(1, 0, 0), NewVector(0, 1, 0)),
+			nil,
+		},
+		{
+			"near, touching along an edge", ClipNear(1),
+			clipTriangle(NewVector(0, 0, 1), NewVector(1, 0, 1), NewVector(0, 1, 0)),
+			nil,
+		},
+		{
+			"screen, all inside", ClipScreen(4, 4),
+			clipTriangle(NewVector(1, 1, 0), NewVector(3, 1, 0), NewVector(1, 3, 0)),
+			[][3]*Vector{{NewVector(1, 1, 0), NewVector(3, 1, 0), NewVector(1, 3, 0)}},
+		},
+		{
+			"screen, none inside", ClipScreen(4, 4),
+			clipTriangle(NewVector(5, 5, 0), NewVector(7, 5, 0), NewVector(5, 7, 0)),
+			nil,
+		},
+		{
+			"screen, one inside", ClipScreen(4, 4),
+			clipTriangle(NewVector(2, 1, 0), NewVector(6, 1, 0), NewVector(6, 3, 0)),
+			[][3]*Vector{{NewVector(2, 1, 0), NewVector(4, 1, 0), NewVector(4, 2, 0)}},
+		},
+		{
+			"screen, two inside", ClipScreen(4, 4),
+			clipTriangle(NewVector(2, 1, 0), NewVector(6, 1, 0), NewVector(2, 3, 0)),
+			[][3]*Vector{
+				{NewVector(2, 1, 0), NewVector(4, 1, 0), NewVector(4, 2, 0)},
+				{NewVector(2, 1, 0), NewVector(4, 2, 0), NewVector(2, 3, 0)},
+			},
+		},
+		{
+			"screen, two inside, reversed winding", ClipScreen(4, 4),
+			clipTriangle(NewVector(2, 1, 0), NewVector(2, 3, 0), NewVector(6, 1, 0)),
+			[][3]*Vector{
+				{NewVector(2, 1, 0), NewVector(2, 3, 0), NewVector(4, 2, 0)},
+				{NewVector(2, 1, 0), NewVector(4, 2, 0), NewVector(4, 1, 0)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.clip(tt.triangle)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d triangles, expected %d", len(got), len(tt.expected))
+			}
+			winding := faceNormal(tt.triangle)
+			for i, t2 := range got {
+				for j, v := range t2.vectors {
+					if !vectorNear(v, tt.expected[i][j]) {
+						t.Errorf("triangle %d vertex %d: got %v, expected %v", i, j, *v, *tt.expected[i][j])
+					}
+				}
+				if faceNormal(t2).DotProduct(winding) <= 0 {
+					t.Errorf("triangle %d: winding reversed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestClipScreenCorner(t *testing.T) {
+	// Clipped by two edges, leaving the 2x2 square in the corner of the screen
+	t1 := clipTriangle(NewVector(2, 2, 0), NewVector(6, 2, 0), NewVector(2, 6, 0))
+	area := 0.0
+	for _, t2 := range ClipScreen(4, 4)(t1) {
+		for _, v := range t2.vectors {
+			if v.X < 0 || v.X > 4 || v.Y < 0 || v.Y > 4 {
+				t.Errorf("vertex %v is off screen", *v)
+			}
+		}
+		n := faceNormal(t2)
+		if n.Z <= 0 {
+			t.Errorf("triangle %v: winding reversed", t2.vectors)
+		}
+		area += n.Z / 2
+	}
+	if math.Abs(area-4) > epsilon {
+		t.Errorf("got area %v, expected 4", area)
+	}
+}
+
+func TestClipAttributes(t *testing.T) {
+	// Each attribute is a different linear function of the position, so clipping must
+	// interpolate it at the same fraction along the edge as the position for it to still hold
+	attributes := []struct {
+		name string
+		get  func(t *Triangle) *[3]*Vector
+		of   func(p *Vector) *Vector
+	}{
+		{"uvs", func(t *Triangle) *[3]*Vector { return &t.uvs }, func(p *Vector) *Vector {
+			return NewVector(p.X*0.5+p.Z, p.Y*0.25, 0)
+		}},
+		{"normals", func(t *Triangle) *[3]*Vector { return &t.normals }, func(p *Vector) *Vector {
+			return NewVectorW(p.Z, -p.X, p.Y*2, 0)
+		}},
+		{"positions", func(t *Triangle) *[3]*Vector { return &t.positions }, func(p *Vector) *Vector {
+			return NewVector(p.X*3, p.Y-1, p.Z*2)
+		}},
+	}
+	// The crossing fractions are 0.25 along one edge and 0.75 along the other
+	near := clipTriangle(NewVector(0, 0, 2), NewVector(4, 0, -2), NewVector(0, 4, -2))
+	screen := clipTriangle(NewVector(3, 1, 1), NewVector(7, 1, 2), NewVector(3, 3, 3))
+	tests := []struct {
+		name     string
+		clip     Clippings
+		triangle *Triangle
+	}{
+		{"near", ClipNear(1), near},
+		{"screen", ClipScreen(4, 4), screen},
+	}
+	for _, tt := range tests {
+		for _, a := range attributes {
+			t.Run(tt.name+" "+a.name, func(t *testing.T) {
+				t1 := *tt.triangle
+				for i, v := range t1.vectors {
+					a.get(&t1)[i] = a.of(v)
+				}
+				got := tt.clip(&t1)
+				if len(got) == 0 {
+					t.Fatal("clipped away")
+				}
+				for _, t2 := range got {
+					for i, v := range t2.vectors {
+						attribute := a.get(t2)[i]
+						if expected := a.of(v); attribute == nil || !vectorNear(attribute, expected) {
+							t.Errorf("vertex %v: got %v, expected %v", *v, attribute, *expected)
+						}
+					}
+					for _, other := range attributes {
+						if other.name != a.name && *other.get(t2) != [3]*Vector{} {
+							t.Errorf("got %s %v, expected them to stay nil", other.name, *other.get(t2))
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestClipPartialAttributes(t *testing.T) {
+	t1 := clipTriangle(NewVector(0, 0, 2), NewVector(4, 0, -2), NewVector(0, 4, -2))
+	t1.uvs = [3]*Vector{NewVector(0, 0, 0), nil, NewVector(1, 1, 0)}
+	got := ClipNear(1)(t1)
+	if len(got) != 1 {
+		t.Fatalf("got %d triangles, expected 1", len(got))
+	}
+	// Only the edge from the second vertex, which has no uv, is missing one
+	expected := [3]bool{true, false, true}
+	for i, uv := range got[0].uvs {
+		if (uv != nil) != expected[i] {
+			t.Errorf("vertex %d: got uv %v, expected present %v", i, uv, expected[i])
+		}
+	}
+}
+
+func TestClipInvisible(t *testing.T) {
+	tests := []struct {
+		name string
+		clip Clippings
+	}{
+		{"near", ClipNear(1)},
+		{"screen", ClipScreen(4, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t1 := NewTriangle(NewVector(-5, -5, -5), NewVector(9, -5, 0.5), NewVector(-5, 9, 3), 0xFFFFFFFF)
+			vectors := t1.vectors
+			got := tt.clip(t1)
+			if len(got) != 1 || got[0] != t1 || got[0].vectors != vectors || got[0].visible {
+				t.Errorf("got %v, expected the invisible triangle back unchanged", got)
+			}
+		})
+	}
+}
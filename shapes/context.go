@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+// RenderContext holds the state of a single view that the later pipeline stages depend on.
+// Each view builds its own, so views with different projections or sizes can render at the
+// same time.
+type RenderContext struct {
+	Projection *Matrix4X4
+	Width      float64
+	Height     float64
+	Near       float64    // view space distance to the near clipping plane
+	View       *Matrix4X4 // moves Lighting into view space, nil when it already is
+	Lighting   *Lighting  // world space lights
+}
+
+func NewRenderContext(projection *Matrix4X4, width, height, near float64) *RenderContext {
+	return &RenderContext{
+		Projection: projection,
+		Width:      width,
+		Height:     height,
+		Near:       near,
+	}
+}
+
+func (rc *RenderContext) ClipNear() Clippings {
+	return ClipNear(rc.Near)
+}
+
+func (rc *RenderContext) Project() Transformations {
+	return Project(rc.Projection)
+}
+
+func (rc *RenderContext) Center() Transformations {
+	return Center(rc.Width/2, rc.Height/2)
+}
+
+func (rc *RenderContext) ClipScreen() Clippings {
+	return ClipScreen(rc.Width, rc.Height)
+}
+
+// Shade lights triangles with the context's lighting, moved into view space. Build it once per
+// view and reuse it for every shape, rather than moving the lights again for each one.
+func (rc *RenderContext) Shade() Transformations {
+	lighting := rc.Lighting
+	if lighting == nil {
+		lighting = NewLighting(RGB{R: 1, G: 1, B: 1})
+	}
+	if rc.View != nil {
+		lighting = lighting.Transform(rc.View)
+	}
+	return Shade(lighting)
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden images in testdata/golden")
+
+const (
+	goldenSize       = 128
+	channelTolerance = 4     // largest difference allowed in any one channel of a pixel
+	maxBadPixels     = 0.002 // fraction of pixels allowed to exceed the channel tolerance
+)
+
+func TestGolden(t *testing.T) {
+	f := 90 * math.Pi / 360
+	projection := Projection(1, 1/f, 0.1, 1000)
+	white := RGB{R: 1, G: 1, B: 1}
+	sun := NewLighting(white.Scale(0.1), DirectionalLight(NewVector(0.3, -0.4, 1), white, 1))
+	lamps := NewLighting(white.Scale(0.05),
+		DirectionalLight(NewVector(0.3, -0.4, 1), white, 0.2),
+		PointLight(NewVector(-3, -2, 7), RGB{R: 1, G: 0.3, B: 0.2}, 4, 20),
+		SpotLight(NewVector(4, -4, 6), NewVector(-4, 4, 3), RGB{R: 0.3, G: 0.5, B: 1}, 8, 30, 0.15, 0.3),
+	)
+	s, err := LoadShapes()
+	if err != nil {
+		t.Fatalf("unable to load shapes: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		shape    string
+		location *Vector
+		rotation *Vector
+		camera   *Vector
+		yaw      float64
+		shading  Shading
+		lighting *Lighting
+	}{
+		{"cube", "cube", NewVector(-0.5, -0.5, 2.5), NewVector(0.4, 0.6, 0), NewVector(0, 0, 0), 0, ShadingFlat, sun},
+		{"teapot", "teapot", NewVector(0, 0, 9), NewVector(0, 0, 0), NewVector(0, 0, 0), 0, ShadingFlat, sun},
+		{"teapot_side", "teapot", NewVector(0, 0, 9), NewVector(0, 0, 0), NewVector(-9, 0, 9), -math.Pi / 2, ShadingFlat, sun},
+		{"teapot_gouraud", "teapot", NewVector(0, 0, 9), NewVector(0, 0, 0), NewVector(0, 0, 0), 0, ShadingGouraud, sun},
+		{"teapot_phong", "teapot", NewVector(0, 0, 9), NewVector(0, 0, 0), NewVector(0, 0, 0), 0, ShadingPhong, sun},
+		{"teapot_lamps", "teapot", NewVector(0, 0, 9), NewVector(0, 0, 0), NewVector(0, 0, 0), 0, ShadingPhong, lamps},
+		{"spaceship", "spaceship", NewVector(0, 0, 8), NewVector(0.3, 2.5, 0), NewVector(0, 0, 0), 0, ShadingFlat, sun},
+		{"axis", "axis", NewVector(0, 0, 16), NewVector(0, 0, 0), NewVector(0, 0, 0), 0, ShadingFlat, sun},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			shape, err := s.Get(tt.shape)
+			if err != nil {
+				t.Fatalf("unable to get %s: %v", tt.shape, err)
+			}
+			shape.Locate(tt.location.X, tt.location.Y, tt.location.Z).
+				Rotate(tt.rotation.X, tt.rotation.Y, tt.rotation.Z).
+				Shading(tt.shading)
+
+			view := CameraMatrix(NewVector(0, 1, 0), tt.camera, NewVector(0, 0, 1), tt.yaw, 0, 0)
+			rc := NewRenderContext(projection, goldenSize, goldenSize, 0.1)
+			rc.View, rc.Lighting = view, tt.lighting
+
+			o := NewOffscreen(goldenSize, goldenSize)
+			o.Clear(0x232323FF)
+			o.Render(shape,
+				WorldMatrices(view),
+				Normal(NewVector(0, 0, 0)),
+				rc.ClipNear(),
+				rc.Project(),
+				rc.Center(),
+				rc.ClipScreen(),
+				rc.Shade(),
+			)
+			compareGolden(t, tt.name, o.Image())
+		})
+	}
+}
+
+// compareGolden checks img against testdata/golden/<name>.png, writing a diff image to
+// testdata/diff/<name>.png when they don't match. With -update the golden is rewritten.
+func compareGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	golden := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := SavePNG(golden, img); err != nil {
+			t.Fatalf("unable to update golden image: %v", err)
+		}
+		return
+	}
+
+	expected, err := readPNG(golden)
+	if err != nil {
+		t.Fatalf("unable to read golden image (run with -update to create it): %v", err)
+	}
+	if !expected.Bounds().Eq(img.Bounds()) {
+		t.Fatalf("image is %v, golden image is %v", img.Bounds(), expected.Bounds())
+	}
+
+	diff, bad := diffImages(expected, img)
+	if limit := int(maxBadPixels * float64(len(img.Pix)/4)); bad > limit {
+		path := filepath.Join("testdata", "diff", name+".png")
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = SavePNG(path, diff)
+		}
+		if err != nil {
+			t.Errorf("unable to write diff image: %v", err)
+		}
+		t.Errorf("%d pixels differ from %s by more than %d, limit is %d; see %s", bad, golden, channelTolerance, limit, path)
+	}
+}
+
+func readPNG(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// diffImages marks pixels beyond the tolerance in red over a faded copy of the expected image
+func diffImages(expected image.Image, actual *image.RGBA) (*image.RGBA, int) {
+	bounds := actual.Bounds()
+	diff := image.NewRGBA(bounds)
+	bad := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			e := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+			a := actual.RGBAAt(x, y)
+			if channelDiff(e.R, a.R) > channelTolerance || channelDiff(e.G, a.G) > channelTolerance ||
+				channelDiff(e.B, a.B) > channelTolerance || channelDiff(e.A, a.A) > channelTolerance {
+				bad++
+				diff.SetRGBA(x, y, color.RGBA{R: 0xFF, A: 0xFF})
+				continue
+			}
+			grey := uint8((uint16(e.R) + uint16(e.G) + uint16(e.B)) / 12)
+			diff.SetRGBA(x, y, color.RGBA{R: grey, G: grey, B: grey, A: 0xFF})
+		}
+	}
+	return diff, bad
+}
+
+func channelDiff(a, b uint8) int {
+	return int(math.Abs(float64(a) - float64(b)))
+}
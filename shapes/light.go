@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"math"
+)
+
+type LightKind int
+
+const (
+	LightDirectional LightKind = iota // shines one way everywhere, like the sun
+	LightPoint                        // shines every way from a position
+	LightSpot                         // shines in a cone from a position
+)
+
+// Attenuation dims point and spot lights with distance d by 1 / (Constant + Linear*d + Quadratic*d*d)
+type Attenuation struct {
+	Constant  float64
+	Linear    float64
+	Quadratic float64
+}
+
+var DefaultAttenuation = Attenuation{Constant: 1, Linear: 0.09, Quadratic: 0.032}
+
+type Light struct {
+	Kind        LightKind
+	Color       RGB
+	Intensity   float64
+	Position    *Vector // point and spot lights
+	Direction   *Vector // the way directional and spot lights travel
+	Range       float64 // distance past which point and spot lights give nothing, 0 for no limit
+	Attenuation Attenuation
+	Inner       float64 // spot half angle, in radians, lit at full strength
+	Outer       float64 // spot half angle, in radians, where the light fades out
+}
+
+func DirectionalLight(direction *Vector, color RGB, intensity float64) *Light {
+	return &Light{
+		Kind:      LightDirectional,
+		Color:     color,
+		Intensity: intensity,
+		Direction: direction.Normalize(),
+	}
+}
+
+func PointLight(position *Vector, color RGB, intensity, rng float64) *Light {
+	return &Light{
+		Kind:        LightPoint,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Range:       rng,
+		Attenuation: DefaultAttenuation,
+	}
+}
+
+func SpotLight(position, direction *Vector, color RGB, intensity, rng, inner, outer float64) *Light {
+	return &Light{
+		Kind:        LightSpot,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Direction:   direction.Normalize(),
+		Range:       rng,
+		Attenuation: DefaultAttenuation,
+		Inner:       inner,
+		Outer:       outer,
+	}
+}
+
+// transform moves the light by a view or model matrix
+func (l *Light) transform(m *Matrix4X4) *Light {
+	l2 := *l
+	if l.Position != nil {
+		l2.Position = NewVector(l.Position.X, l.Position.Y, l.Position.Z).MatrixMultiply(m)
+	}
+	if l.Direction != nil {
+		l2.Direction = NewVectorW(l.Direction.X, l.Direction.Y, l.Direction.Z, 0).MatrixMultiply(m).Normalize()
+	}
+	return &l2
+}
+
+// illuminate is how much of the light reaches a surface at position facing normal, where both
+// are in the same space as the light
+func (l *Light) illuminate(position, normal *Vector) RGB {
+	direction := l.Direction
+	strength := l.Intensity
+	if l.Kind != LightDirectional {
+		toSurface := position.Subtract(l.Position)
+		d := toSurface.Length()
+		if d == 0 || (l.Range > 0 && d > l.Range) {
+			return RGB{}
+		}
+		direction = toSurface.Divide(d)
+		a := l.Attenuation
+		strength /= max(1e-9, a.Constant+a.Linear*d+a.Quadratic*d*d)
+		if l.Kind == LightSpot {
+			strength *= l.cone(direction)
+		}
+	}
+	return l.Color.Scale(strength * max(0, normal.DotProduct(direction)))
+}
+
+// cone fades a spot light smoothly from full strength at the inner angle to nothing at the outer
+func (l *Light) cone(direction *Vector) float64 {
+	cos := direction.DotProduct(l.Direction)
+	inner, outer := math.Cos(l.Inner), math.Cos(l.Outer)
+	if inner <= outer {
+		if cos >= outer {
+			return 1
+		}
+		return 0
+	}
+	f := min(1, max(0, (cos-outer)/(inner-outer)))
+	return f * f * (3 - 2*f)
+}
+
+// Lighting is every light in a scene, along with the ambient light that reaches everywhere
+type Lighting struct {
+	Ambient RGB
+	Lights  []*Light
+}
+
+func NewLighting(ambient RGB, lights ...*Light) *Lighting {
+	return &Lighting{
+		Ambient: ambient,
+		Lights:  lights,
+	}
+}
+
+// Transform moves every light by m, usually a view matrix
+func (l *Lighting) Transform(m *Matrix4X4) *Lighting {
+	l2 := &Lighting{Ambient: l.Ambient, Lights: make([]*Light, len(l.Lights))}
+	for i, light := range l.Lights {
+		l2.Lights[i] = light.transform(m)
+	}
+	return l2
+}
+
+// illuminate sums the ambient light and every light reaching a surface
+func (l *Lighting) illuminate(position, normal *Vector) RGB {
+	total := l.Ambient
+	for _, light := range l.Lights {
+		total = total.Add(light.illuminate(position, normal))
+	}
+	return total
+}
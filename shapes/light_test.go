@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIlluminate(t *testing.T) {
+	white := RGB{R: 1, G: 1, B: 1}
+	facing := NewVector(0, 0, 1) // normals point the way light travels to reach them
+	origin := NewVector(0, 0, 0)
+	point := PointLight(NewVector(0, 0, -2), white, 1, 10)
+	point.Attenuation = Attenuation{Constant: 1, Quadratic: 1}
+	spot := SpotLight(NewVector(0, 0, -2), NewVector(0, 0, 1), white, 1, 10, 0.2, 0.4)
+	spot.Attenuation = Attenuation{Constant: 1}
+	halfway := math.Acos((math.Cos(0.2) + math.Cos(0.4)) / 2) // where the spot fades to half
+
+	tests := []struct {
+		name     string
+		light    *Light
+		position *Vector
+		normal   *Vector
+		expected float64
+	}{
+		{"directional head on", DirectionalLight(NewVector(0, 0, 1), white, 0.5), origin, facing, 0.5},
+		{"directional edge on", DirectionalLight(NewVector(1, 0, 0), white, 1), origin, facing, 0},
+		{"directional behind", DirectionalLight(NewVector(0, 0, -1), white, 1), origin, facing, 0},
+		{"point attenuated", point, origin, facing, 1.0 / 5},
+		{"point out of range", point, NewVector(0, 0, 9), facing, 0},
+		{"spot inside cone", spot, origin, facing, 1},
+		{"spot outside cone", spot, NewVector(2, 0, 0), facing, 0},
+		{"spot fading", spot, NewVector(2*math.Tan(halfway), 0, 0), facing, 0.5 * math.Cos(halfway)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.light.illuminate(tt.position, tt.normal)
+			if math.Abs(got.R-tt.expected) > 0.02 || got.R != got.G || got.G != got.B {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLightingTransform(t *testing.T) {
+	lighting := NewLighting(RGB{R: 0.1, G: 0.1, B: 0.1}, PointLight(NewVector(1, 2, 3), RGB{R: 1}, 1, 0))
+	moved := lighting.Transform(Translation(1, 1, 1))
+	if got := moved.Lights[0].Position; !vectorNear(got, NewVector(2, 3, 4)) {
+		t.Errorf("got %v, expected {2 3 4}", *got)
+	}
+	if got := lighting.Lights[0].Position; !vectorNear(got, NewVector(1, 2, 3)) {
+		t.Errorf("original light moved to %v", *got)
+	}
+	if moved.Ambient != lighting.Ambient {
+		t.Errorf("ambient changed to %v", moved.Ambient)
+	}
+}
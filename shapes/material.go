@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"io"
+	"strconv"
+)
+
+type RGB struct {
+	R float64
+	G float64
+	B float64
+}
+
+func unpackRGB(color uint32) (RGB, float64) {
+	return RGB{
+		R: float64(uint8(color>>24)) / 0xFF,
+		G: float64(uint8(color>>16)) / 0xFF,
+		B: float64(uint8(color>>8)) / 0xFF,
+	}, float64(uint8(color)) / 0xFF
+}
+
+func (c RGB) Scale(f float64) RGB {
+	return RGB{R: c.R * f, G: c.G * f, B: c.B * f}
+}
+
+func (c RGB) Add(c1 RGB) RGB {
+	return RGB{R: c.R + c1.R, G: c.G + c1.G, B: c.B + c1.B}
+}
+
+// Multiply filters one color by another, channel by channel
+func (c RGB) Multiply(c1 RGB) RGB {
+	return RGB{R: c.R * c1.R, G: c.G * c1.G, B: c.B * c1.B}
+}
+
+// pack converts the color to RGBA8888, clamping each channel to [0, 1]
+func (c RGB) pack(alpha float64) uint32 {
+	channel := func(f float64) uint32 {
+		return uint32(min(1, max(0, f)) * 0xFF)
+	}
+	return channel(c.R)<<24 | channel(c.G)<<16 | channel(c.B)<<8 | channel(alpha)
+}
+
+type Material struct {
+	Name       string
+	Ambient    RGB     // Ka
+	Diffuse    RGB     // Kd
+	Specular   RGB     // Ks
+	Shininess  float64 // Ns
+	Dissolve   float64 // d, 1 is opaque
+	Illum      int     // illumination model
+	DiffuseMap string  // map_Kd
+}
+
+func NewMaterial(name string) *Material {
+	return &Material{
+		Name:     name,
+		Diffuse:  RGB{R: 1, G: 1, B: 1},
+		Dissolve: 1,
+	}
+}
+
+// mtlParser reads a Wavefront MTL material library into materials, keyed by name
+type mtlParser struct {
+	lineParser
+	materials map[string]*Material
+	material  *Material
+}
+
+func (p *mtlParser) parse(r io.Reader) error {
+	return p.scan(r, p.parseLine)
+}
+
+func (p *mtlParser) parseLine(fields []string) error {
+	if fields[0] == "newmtl" {
+		if len(fields) != 2 {
+			return p.errorf(0, "newmtl takes a single material name")
+		}
+		p.material = NewMaterial(fields[1])
+		p.materials[p.material.Name] = p.material
+		return nil
+	}
+	if p.material == nil {
+		return nil
+	}
+
+	var err error
+	switch fields[0] {
+	case "Ka":
+		p.material.Ambient, err = p.parseRGB(fields)
+	case "Kd":
+		p.material.Diffuse, err = p.parseRGB(fields)
+	case "Ks":
+		p.material.Specular, err = p.parseRGB(fields)
+	case "Ns":
+		p.material.Shininess, err = p.parseFloat(fields)
+	case "d":
+		p.material.Dissolve, err = p.parseFloat(fields)
+	case "Tr":
+		var tr float64
+		tr, err = p.parseFloat(fields)
+		p.material.Dissolve = 1 - tr
+	case "illum":
+		if len(fields) != 2 {
+			return p.errorf(0, "illum takes a single illumination model")
+		}
+		if p.material.Illum, err = strconv.Atoi(fields[1]); err != nil {
+			return p.errorf(1, "bad illumination model %q", fields[1])
+		}
+	case "map_Kd":
+		// Any options precede the file name
+		if len(fields) < 2 {
+			return p.errorf(0, "map_Kd requires a file name")
+		}
+		p.material.DiffuseMap = fields[len(fields)-1]
+	}
+	return err
+}
+
+func (p *mtlParser) parseFloat(fields []string) (float64, error) {
+	f, err := p.parseFloats(fields, 1, 1)
+	return f[0], err
+}
+
+// parseRGB takes either a single grey level or separate red, green and blue values
+func (p *mtlParser) parseRGB(fields []string) (RGB, error) {
+	if len(fields) == 3 {
+		return RGB{}, p.errorf(0, "%s takes 1 or 3 values, found 2", fields[0])
+	}
+	rgb, err := p.parseFloats(fields, 1, 3)
+	if len(fields) == 2 {
+		rgb[1], rgb[2] = rgb[0], rgb[0]
+	}
+	return RGB{R: rgb[0], G: rgb[1], B: rgb[2]}, err
+}
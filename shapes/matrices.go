@@ -5,11 +5,14 @@
 package shapes
 
 import (
+	"errors"
 	"math"
 )
 
 type Matrix4X4 [4][4]float64
 
+var ErrSingularMatrix = errors.New("matrix is singular")
+
 var (
 	identity = &Matrix4X4{
 		{1, 0, 0, 0},
@@ -32,6 +35,15 @@ func Translation(x, y, z float64) *Matrix4X4 {
 	}
 }
 
+func Scaling(x, y, z float64) *Matrix4X4 {
+	return &Matrix4X4{
+		{x, 0, 0, 0},
+		{0, y, 0, 0},
+		{0, 0, z, 0},
+		{0, 0, 0, 1},
+	}
+}
+
 func Projection(aspectRatio, fovRad, near, far float64) *Matrix4X4 {
 	return &Matrix4X4{
 		{aspectRatio * fovRad, 0, 0, 0},
@@ -41,6 +53,17 @@ func Projection(aspectRatio, fovRad, near, far float64) *Matrix4X4 {
 	}
 }
 
+// Orthographic maps the box from left, bottom, near to right, top, far onto x and y in
+// [-1, 1] and z in [0, 1], the same range Projection produces
+func Orthographic(left, right, bottom, top, near, far float64) *Matrix4X4 {
+	return &Matrix4X4{
+		{2 / (right - left), 0, 0, 0},
+		{0, 2 / (top - bottom), 0, 0},
+		{0, 0, 1 / (far - near), 0},
+		{-(right + left) / (right - left), -(top + bottom) / (top - bottom), -near / (far - near), 1},
+	}
+}
+
 func RotationX(angle float64) *Matrix4X4 {
 	return &Matrix4X4{
 		{1, 0, 0, 0},
@@ -67,6 +90,21 @@ func RotationZ(angle float64) *Matrix4X4 {
 	}
 }
 
+// RotationAxis rotates about an arbitrary axis with the same handedness as RotationX,
+// RotationY and RotationZ
+func RotationAxis(axis *Vector, angle float64) *Matrix4X4 {
+	a := axis.Normalize()
+	c := math.Cos(angle)
+	s := math.Sin(angle)
+	t := 1 - c
+	return &Matrix4X4{
+		{c + a.X*a.X*t, a.X*a.Y*t - a.Z*s, a.X*a.Z*t + a.Y*s, 0},
+		{a.Y*a.X*t + a.Z*s, c + a.Y*a.Y*t, a.Y*a.Z*t - a.X*s, 0},
+		{a.Z*a.X*t - a.Y*s, a.Z*a.Y*t + a.X*s, c + a.Z*a.Z*t, 0},
+		{0, 0, 0, 1},
+	}
+}
+
 func PointAt(pos, target, up *Vector) *Matrix4X4 {
 	newForward := target.Subtract(pos).Normalize()
 	newUp := up.Subtract(newForward.Multiply(up.DotProduct(newForward))).Normalize()
@@ -87,10 +125,7 @@ func LookAt(pos, target, up *Vector) *Matrix4X4 {
 		{newRight.X, newUp.X, newForward.X, 0},
 		{newRight.Y, newUp.Y, newForward.Y, 0},
 		{newRight.Z, newUp.Z, newForward.Z, 0},
-		{
-			-(pos.X*newRight.X + pos.Y*newUp.X + pos.Z*newForward.X),
-			-(pos.X*newRight.Y + pos.Y*newUp.Y + pos.Z*newForward.Y),
-			-(pos.Z*newRight.Z + pos.Z*newUp.Z + pos.Z*newForward.Z), 1},
+		{-pos.DotProduct(newRight), -pos.DotProduct(newUp), -pos.DotProduct(newForward), 1},
 	}
 }
 
@@ -103,3 +138,96 @@ func (m *Matrix4X4) Multiply(m1 *Matrix4X4) *Matrix4X4 {
 	}
 	return mo
 }
+
+func (m *Matrix4X4) Transpose() *Matrix4X4 {
+	mo := &Matrix4X4{}
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			mo[c][r] = m[r][c]
+		}
+	}
+	return mo
+}
+
+func (m *Matrix4X4) Determinant() float64 {
+	u := *m
+	det := 1.0
+	for c := 0; c < 4; c++ {
+		p := pivot(&u, c)
+		if u[p][c] == 0 {
+			return 0
+		}
+		if p != c {
+			u[p], u[c] = u[c], u[p]
+			det = -det
+		}
+		det *= u[c][c]
+		for r := c + 1; r < 4; r++ {
+			f := u[r][c] / u[c][c]
+			for k := c; k < 4; k++ {
+				u[r][k] -= f * u[c][k]
+			}
+		}
+	}
+	return det
+}
+
+// Inverse uses Gauss-Jordan elimination with partial pivoting
+func (m *Matrix4X4) Inverse() (*Matrix4X4, error) {
+	u := *m
+	inv := *identity
+	for c := 0; c < 4; c++ {
+		p := pivot(&u, c)
+		if math.Abs(u[p][c]) < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		u[p], u[c] = u[c], u[p]
+		inv[p], inv[c] = inv[c], inv[p]
+
+		f := u[c][c]
+		for k := 0; k < 4; k++ {
+			u[c][k] /= f
+			inv[c][k] /= f
+		}
+		for r := 0; r < 4; r++ {
+			if r == c || u[r][c] == 0 {
+				continue
+			}
+			f = u[r][c]
+			for k := 0; k < 4; k++ {
+				u[r][k] -= f * u[c][k]
+				inv[r][k] -= f * inv[c][k]
+			}
+		}
+	}
+	return &inv, nil
+}
+
+// NormalMatrix transforms normals to stay perpendicular to surfaces moved by m: the inverse
+// transpose, negated when m mirrors, so normals built from cross products keep their sign
+func (m *Matrix4X4) NormalMatrix() *Matrix4X4 {
+	inv, err := m.Inverse()
+	if err != nil {
+		return m
+	}
+	n := inv.Transpose()
+	if m.Determinant() < 0 {
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				n[r][c] = -n[r][c]
+			}
+		}
+	}
+	return n
+}
+
+// pivot finds the row, at or below c, with the largest magnitude in column c
+func pivot(m *Matrix4X4, c int) int {
+	p := c
+	for r := c + 1; r < 4; r++ {
+		if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+			p = r
+		}
+	}
+	return p
+}
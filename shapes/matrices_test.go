@@ -0,0 +1,238 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func matrixNear(a, b *Matrix4X4) bool {
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			if math.Abs(a[r][c]-b[r][c]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func vectorNear(a, b *Vector) bool {
+	return math.Abs(a.X-b.X) <= epsilon && math.Abs(a.Y-b.Y) <= epsilon && math.Abs(a.Z-b.Z) <= epsilon
+}
+
+func TestScaling(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Matrix4X4
+		v        *Vector
+		expected *Vector
+	}{
+		{"unit", Scaling(1, 1, 1), NewVector(1, 2, 3), NewVector(1, 2, 3)},
+		{"uniform", Scaling(2, 2, 2), NewVector(1, 2, 3), NewVector(2, 4, 6)},
+		{"non-uniform", Scaling(1, -1, 0.5), NewVector(1, 2, 3), NewVector(1, -2, 1.5)},
+		{"keeps w", Scaling(3, 3, 3), NewVectorW(1, 1, 1, 0), NewVectorW(3, 3, 3, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.v.MatrixMultiply(tt.m)
+			if !vectorNear(got, tt.expected) || got.W != tt.expected.W {
+				t.Errorf("got %v, expected %v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Matrix4X4
+		expected *Matrix4X4
+	}{
+		{"identity", Identity(), Identity()},
+		{"translation", Translation(1, 2, 3), &Matrix4X4{{1, 0, 0, 1}, {0, 1, 0, 2}, {0, 0, 1, 3}, {0, 0, 0, 1}}},
+		{"rotation", RotationX(0.7), RotationX(-0.7)},
+		{
+			"general",
+			&Matrix4X4{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13, 14, 15, 16}},
+			&Matrix4X4{{1, 5, 9, 13}, {2, 6, 10, 14}, {3, 7, 11, 15}, {4, 8, 12, 16}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Transpose(); !matrixNear(got, tt.expected) {
+				t.Errorf("got %v, expected %v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeterminant(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Matrix4X4
+		expected float64
+	}{
+		{"identity", Identity(), 1},
+		{"translation", Translation(5, -3, 2), 1},
+		{"scaling", Scaling(2, 3, 4), 24},
+		{"mirror", Scaling(1, -1, 1), -1},
+		{"rotation", RotationAxis(NewVector(1, 2, 3), 1.2), 1},
+		{"row swap", &Matrix4X4{{0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}, -1},
+		{"singular", &Matrix4X4{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13, 14, 15, 16}}, 0},
+		{"general", &Matrix4X4{{2, 0, 1, 3}, {1, 1, 0, 2}, {0, 3, 1, 1}, {1, 0, 2, 1}}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Determinant(); math.Abs(got-tt.expected) > epsilon {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInverse(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix4X4
+	}{
+		{"identity", Identity()},
+		{"translation", Translation(5, -3, 2)},
+		{"scaling", Scaling(2, 3, 4)},
+		{"rotation", RotationAxis(NewVector(-1, 2, 0.5), 2.1)},
+		{"model", Scaling(2, 2, 2).Multiply(RotationY(0.4)).Multiply(Translation(1, 2, 3))},
+		{"projection", Projection(0.5, 1, 0.1, 1000)},
+		{"orthographic", Orthographic(-4, 4, -3, 3, 0.1, 100)},
+		{"general", &Matrix4X4{{2, 0, 1, 3}, {1, 1, 0, 2}, {0, 3, 1, 1}, {1, 0, 2, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, err := tt.m.Inverse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := tt.m.Multiply(inv); !matrixNear(got, Identity()) {
+				t.Errorf("m * inverse = %v, expected identity", *got)
+			}
+			if got := inv.Multiply(tt.m); !matrixNear(got, Identity()) {
+				t.Errorf("inverse * m = %v, expected identity", *got)
+			}
+		})
+	}
+}
+
+func TestInverseSingular(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix4X4
+	}{
+		{"zero", &Matrix4X4{}},
+		{"flattened", Scaling(1, 0, 1)},
+		{"dependent rows", &Matrix4X4{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13, 14, 15, 16}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.m.Inverse(); !errors.Is(err, ErrSingularMatrix) {
+				t.Errorf("got %v, expected %v", err, ErrSingularMatrix)
+			}
+		})
+	}
+}
+
+func TestInversePointAtLookAt(t *testing.T) {
+	tests := []struct {
+		name            string
+		pos, target, up *Vector
+	}{
+		{"origin", NewVector(0, 0, 0), NewVector(0, 0, 1), NewVector(0, 1, 0)},
+		{"offset", NewVector(1, 2, 3), NewVector(4, 2, 7), NewVector(0, 1, 0)},
+		{"tilted", NewVector(-5, 1, 2), NewVector(0, 3, 0), NewVector(0.2, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, err := PointAt(tt.pos, tt.target, tt.up).Inverse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := LookAt(tt.pos, tt.target, tt.up); !matrixNear(inv, expected) {
+				t.Errorf("got %v, expected %v", *inv, *expected)
+			}
+		})
+	}
+}
+
+func TestRotationAxis(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Matrix4X4
+		expected *Matrix4X4
+	}{
+		{"x", RotationAxis(NewVector(1, 0, 0), 0.8), RotationX(0.8)},
+		{"y", RotationAxis(NewVector(0, 1, 0), -1.3), RotationY(-1.3)},
+		{"z", RotationAxis(NewVector(0, 0, 1), 2.9), RotationZ(2.9)},
+		{"unnormalised", RotationAxis(NewVector(0, 5, 0), 0.3), RotationY(0.3)},
+		{"zero angle", RotationAxis(NewVector(1, 2, 3), 0), Identity()},
+		{"quaternion", RotationAxis(NewVector(1, -2, 3), 1.1), QuaternionAxisAngle(NewVector(1, -2, 3), 1.1).Matrix()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !matrixNear(tt.m, tt.expected) {
+				t.Errorf("got %v, expected %v", *tt.m, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestOrthographic(t *testing.T) {
+	m := Orthographic(-4, 4, -3, 3, 1, 11)
+	tests := []struct {
+		name     string
+		v        *Vector
+		expected *Vector
+	}{
+		{"near bottom left", NewVector(-4, -3, 1), NewVector(-1, -1, 0)},
+		{"far top right", NewVector(4, 3, 11), NewVector(1, 1, 1)},
+		{"center", NewVector(0, 0, 6), NewVector(0, 0, 0.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.v.MatrixMultiply(m)
+			if !vectorNear(got, tt.expected) || got.W != 1 {
+				t.Errorf("got %v, expected %v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix4X4
+	}{
+		{"identity", Identity()},
+		{"translation", Translation(5, -3, 2)},
+		{"non-uniform scaling", Scaling(2, 0.5, 3)},
+		{"mirror", Scaling(1, -1, 1)},
+		{"model", Scaling(1, 4, 2).Multiply(RotationAxis(NewVector(1, 1, 0), 0.9)).Multiply(Translation(1, 2, 3))},
+		{"look at", LookAt(NewVector(1, 2, 3), NewVector(4, 2, 7), NewVector(0, 1, 0))},
+	}
+	// Tangents a and b, and their normal, as directions that translation leaves alone
+	a, b := NewVectorW(1, 0.5, 0, 0), NewVectorW(0, 1, 2, 0)
+	c := a.CrossProduct(b)
+	n := NewVectorW(c.X, c.Y, c.Z, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := n.MatrixMultiply(tt.m.NormalMatrix()).Normalize()
+			expected := a.MatrixMultiply(tt.m).CrossProduct(b.MatrixMultiply(tt.m)).Normalize()
+			if !vectorNear(got, expected) {
+				t.Errorf("got %v, expected %v", *got, *expected)
+			}
+		})
+	}
+}
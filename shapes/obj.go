@@ -0,0 +1,348 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError reports a malformed line in an OBJ or MTL file. Line and Column are 1-based;
+// a Column of 0 means the problem is with the line as a whole.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File)
+		sb.WriteByte(':')
+	}
+	_, _ = fmt.Fprintf(&sb, "%d:%d: %s", e.Line, e.Column, e.Msg)
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// LoadOBJ reads a Wavefront OBJ shape. Material libraries can't be located from a reader,
+// so any mtllib and usemtl statements are skipped.
+func LoadOBJ(r io.Reader) (*Shape, error) {
+	return loadOBJ(r, "", nil)
+}
+
+// LoadOBJFile reads a Wavefront OBJ shape from a file, resolving material libraries
+// relative to it.
+func LoadOBJFile(filename string) (*Shape, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(filename)
+	return loadOBJ(file, filename, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, name))
+	})
+}
+
+// LoadOBJFS reads a Wavefront OBJ shape from a file system, resolving material libraries
+// relative to it.
+func LoadOBJFS(fsys fs.FS, name string) (*Shape, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := path.Dir(name)
+	return loadOBJ(file, name, func(name string) (io.ReadCloser, error) {
+		return fsys.Open(path.Join(dir, name))
+	})
+}
+
+func loadOBJ(r io.Reader, name string, open func(name string) (io.ReadCloser, error)) (*Shape, error) {
+	p := &objParser{
+		lineParser: lineParser{file: name},
+		open:       open,
+	}
+	if err := p.parse(r); err != nil {
+		return nil, err
+	}
+	return newShape(p.ts), nil
+}
+
+// objParser reads Wavefront OBJ geometry: positions, texture coordinates, normals and
+// polygonal faces, which are fan triangulated
+type objParser struct {
+	lineParser
+	open      func(name string) (io.ReadCloser, error) // resolves material libraries
+	pts       []*Vector
+	uvs       []*Vector
+	nms       []*Vector
+	ts        []*Triangle
+	materials map[string]*Material
+	material  *Material
+}
+
+func (p *objParser) parse(r io.Reader) error {
+	return p.scan(r, p.parseLine)
+}
+
+func (p *objParser) parseLine(fields []string) error {
+	switch fields[0] {
+	case "v":
+		xyz, err := p.parseFloats(fields, 3, 4)
+		if err != nil {
+			return err
+		}
+		p.pts = append(p.pts, NewVector(xyz[0], xyz[1], xyz[2]))
+	case "vt":
+		uvw, err := p.parseFloats(fields, 1, 3)
+		if err != nil {
+			return err
+		}
+		p.uvs = append(p.uvs, NewVector(uvw[0], uvw[1], uvw[2]))
+	case "vn":
+		xyz, err := p.parseFloats(fields, 3, 3)
+		if err != nil {
+			return err
+		}
+		p.nms = append(p.nms, NewVectorW(xyz[0], xyz[1], xyz[2], 0))
+	case "f":
+		return p.parseFace(fields)
+	case "mtllib":
+		return p.loadMaterials(fields)
+	case "usemtl":
+		if len(fields) != 2 {
+			return p.errorf(0, "usemtl takes a single material name")
+		}
+		// Without a way to open material libraries there are no materials to find
+		if p.open == nil {
+			return nil
+		}
+		material, ok := p.materials[fields[1]]
+		if !ok {
+			return p.errorf(1, "unknown material %q", fields[1])
+		}
+		p.material = material
+	}
+	return nil
+}
+
+func (p *objParser) loadMaterials(fields []string) error {
+	if len(fields) < 2 {
+		return p.errorf(0, "mtllib requires a file name")
+	}
+	if p.materials == nil {
+		p.materials = map[string]*Material{}
+	}
+	if p.open == nil {
+		return nil
+	}
+	for i, name := range fields[1:] {
+		r, err := p.open(name)
+		if err != nil {
+			return &ParseError{
+				File:   p.file,
+				Line:   p.lineCnt,
+				Column: p.columns[i+1],
+				Msg:    "unable to open material library",
+				Err:    err,
+			}
+		}
+		mp := &mtlParser{
+			lineParser: lineParser{file: name},
+			materials:  p.materials,
+		}
+		err = mp.parse(r)
+		_ = r.Close()
+		if err != nil {
+			return &ParseError{
+				File:   p.file,
+				Line:   p.lineCnt,
+				Column: p.columns[i+1],
+				Msg:    "bad material library",
+				Err:    err,
+			}
+		}
+	}
+	return nil
+}
+
+func (p *objParser) parseFace(fields []string) error {
+	if len(fields) < 4 {
+		return p.errorf(0, "face needs at least 3 vertices, found %d", len(fields)-1)
+	}
+	n := len(fields) - 1
+	pts := make([]*Vector, n)
+	uvs := make([]*Vector, n)
+	nms := make([]*Vector, n)
+	for i := 0; i < n; i++ {
+		refs := strings.Split(fields[i+1], "/")
+		if len(refs) > 3 {
+			return p.errorf(i+1, "bad face vertex %q", fields[i+1])
+		}
+		var err error
+		if pts[i], err = p.resolve(i+1, refs[0], p.pts, true); err != nil {
+			return err
+		}
+		if len(refs) > 1 {
+			if uvs[i], err = p.resolve(i+1, refs[1], p.uvs, false); err != nil {
+				return err
+			}
+		}
+		if len(refs) > 2 {
+			if nms[i], err = p.resolve(i+1, refs[2], p.nms, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := 1; i+1 < n; i++ {
+		p.ts = append(p.ts, &Triangle{
+			vectors:  [3]*Vector{pts[0], pts[i], pts[i+1]},
+			uvs:      [3]*Vector{uvs[0], uvs[i], uvs[i+1]},
+			normals:  [3]*Vector{nms[0], nms[i], nms[i+1]},
+			normal:   NewVector(0, 0, 0),
+			visible:  true,
+			color:    uint32(0xFFFFFFFF),
+			material: p.material,
+		})
+	}
+	return nil
+}
+
+// resolve looks up a 1-based index, or a negative index relative to the end of the list
+func (p *objParser) resolve(field int, ref string, vs []*Vector, required bool) (*Vector, error) {
+	if ref == "" {
+		if required {
+			return nil, p.errorf(field, "missing vertex index")
+		}
+		return nil, nil
+	}
+	idx, err := strconv.Atoi(ref)
+	if err != nil {
+		return nil, p.errorf(field, "bad index %q", ref)
+	}
+	if idx < 0 {
+		idx += len(vs)
+	} else {
+		idx--
+	}
+	if idx < 0 || idx >= len(vs) {
+		return nil, p.errorf(field, "index %s out of range", ref)
+	}
+	return vs[idx], nil
+}
+
+type lineParser struct {
+	file    string
+	lineCnt int
+	columns []int // 1-based column of each field on the current line
+}
+
+// scan feeds the fields of each non-empty line, with comments removed, to parseLine
+func (p *lineParser) scan(r io.Reader, parseLine func(fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.lineCnt++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		var fields []string
+		fields, p.columns = splitFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := parseLine(fields); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &ParseError{File: p.file, Line: p.lineCnt + 1, Msg: "unable to read line", Err: err}
+	}
+	return nil
+}
+
+// parseFloats parses between minCnt and maxCnt values following the keyword, padding
+// missing optional values with zero
+func (p *lineParser) parseFloats(fields []string, minCnt, maxCnt int) ([4]float64, error) {
+	var values [4]float64
+	if len(fields)-1 < minCnt {
+		return values, p.errorf(0, "%s needs at least %d values, found %d", fields[0], minCnt, len(fields)-1)
+	}
+	if len(fields)-1 > maxCnt {
+		return values, p.errorf(maxCnt+1, "%s takes at most %d values, found %d", fields[0], maxCnt, len(fields)-1)
+	}
+	for i, field := range fields[1:] {
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return values, p.errorf(i+1, "bad number %q", field)
+		}
+		values[i] = f
+	}
+	return values, nil
+}
+
+// errorf reports a problem with the given field of the current line, or with the whole
+// line for field 0
+func (p *lineParser) errorf(field int, format string, args ...any) error {
+	column := 0
+	if field > 0 && field < len(p.columns) {
+		column = p.columns[field]
+	}
+	return &ParseError{
+		File:   p.file,
+		Line:   p.lineCnt,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// splitFields splits a line on white space, like strings.Fields, also returning the
+// 1-based column each field starts at
+func splitFields(line string) ([]string, []int) {
+	var fields []string
+	var columns []int
+	start := -1
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			fields = append(fields, line[start:i])
+			columns = append(columns, start+1)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, line[start:])
+		columns = append(columns, start+1)
+	}
+	return fields, columns
+}
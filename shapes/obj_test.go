@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const triangle = "v 0 0 0\nv 1 0 0\nv 0 1 0\n"
+
+func TestLoadOBJ(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       string
+		triangles int
+	}{
+		{"triangle", triangle + "f 1 2 3", 1},
+		{"quad", triangle + "v 1 1 0\nf 1 2 4 3", 2},
+		{"relative", triangle + "f -3 -2 -1", 1},
+		{"slashes", triangle + "vt 0 0\nvn 0 0 1\nf 1/1/1 2//1 3/1", 1},
+		{"comments", "# header\n" + triangle + "f 1 2 3 # trailing", 1},
+		{"mtllib skipped", "mtllib missing.mtl\n" + triangle + "f 1 2 3", 1},
+		{"usemtl skipped", "mtllib missing.mtl\nusemtl red\n" + triangle + "f 1 2 3", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := LoadOBJ(strings.NewReader(tt.obj))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(shape.ts) != tt.triangles {
+				t.Errorf("got %d triangles, expected %d", len(shape.ts), tt.triangles)
+			}
+		})
+	}
+}
+
+func TestLoadOBJAttributes(t *testing.T) {
+	header := "v 0 0 0\nv 1 0 0\nv 0 1 0\nv 1 1 0\nvt 0.1 0.2\nvt 0.3 0.4\nvn 0 1 0\nvn 1 0 0\n"
+	// The last point is only added by the pentagon
+	pts := []*Vector{NewVector(0, 0, 0), NewVector(1, 0, 0), NewVector(0, 1, 0), NewVector(1, 1, 0), NewVector(0.5, 1.5, 0)}
+	uvs := []*Vector{NewVector(0.1, 0.2, 0), NewVector(0.3, 0.4, 0)}
+	nms := []*Vector{NewVectorW(0, 1, 0, 0), NewVectorW(1, 0, 0, 0)}
+
+	// Indices are 0-based into pts, uvs and nms, with -1 for a vertex that gives none
+	type face struct {
+		vectors [3]int
+		uvs     [3]int
+		normals [3]int
+	}
+	none := [3]int{-1, -1, -1}
+	tests := []struct {
+		name     string
+		faces    string
+		expected []face
+	}{
+		{"absolute", "f 1 2 3", []face{{[3]int{0, 1, 2}, none, none}}},
+		{"relative", "f -4 -3 -2", []face{{[3]int{0, 1, 2}, none, none}}},
+		{"mixed", "f 1 -2 -1", []face{{[3]int{0, 2, 3}, none, none}}},
+		{"quad fan", "f 1 2 4 3", []face{{[3]int{0, 1, 3}, none, none}, {[3]int{0, 3, 2}, none, none}}},
+		{"uvs", "f 1/2 2/1 3/2", []face{{[3]int{0, 1, 2}, [3]int{1, 0, 1}, none}}},
+		{"normals", "f 1//2 2//1 3//2", []face{{[3]int{0, 1, 2}, none, [3]int{1, 0, 1}}}},
+		{"mixed slashes", "f 1/2/1 2//1 3/1", []face{{[3]int{0, 1, 2}, [3]int{1, -1, 0}, [3]int{0, 0, -1}}}},
+		{"relative slashes", "f -4/-1/-2 -3/-2/-1 -2/-1/-1", []face{{[3]int{0, 1, 2}, [3]int{1, 0, 1}, [3]int{0, 1, 1}}}},
+		{
+			"pentagon fan", "v 0.5 1.5 0\nf 1 2 4 5 3",
+			[]face{{[3]int{0, 1, 3}, none, none}, {[3]int{0, 3, 4}, none, none}, {[3]int{0, 4, 2}, none, none}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := LoadOBJ(strings.NewReader(header + tt.faces))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(shape.ts) != len(tt.expected) {
+				t.Fatalf("got %d triangles, expected %d", len(shape.ts), len(tt.expected))
+			}
+			for i, f := range tt.expected {
+				tr := shape.ts[i]
+				for j := 0; j < 3; j++ {
+					if !vectorNear(tr.vectors[j], pts[f.vectors[j]]) {
+						t.Errorf("triangle %d vertex %d: got %v, expected %v", i, j, *tr.vectors[j], *pts[f.vectors[j]])
+					}
+					if f.uvs[j] < 0 {
+						if tr.uvs[j] != nil {
+							t.Errorf("triangle %d vertex %d: got uv %v, expected none", i, j, *tr.uvs[j])
+						}
+					} else if tr.uvs[j] == nil || !vectorNear(tr.uvs[j], uvs[f.uvs[j]]) {
+						t.Errorf("triangle %d vertex %d: got uv %v, expected %v", i, j, tr.uvs[j], *uvs[f.uvs[j]])
+					}
+					// Vertices without a normal of their own are given a smoothed one
+					if f.normals[j] >= 0 && (tr.normals[j] == nil || !vectorNear(tr.normals[j], nms[f.normals[j]])) {
+						t.Errorf("triangle %d vertex %d: got normal %v, expected %v", i, j, tr.normals[j], *nms[f.normals[j]])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoadOBJErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    string
+		mtl    string
+		file   string
+		line   int
+		column int
+	}{
+		{"bad number", "v 1 x 3", "", "model.obj", 1, 5},
+		{"too many values", "vn 1 0 0 1", "", "model.obj", 1, 10},
+		{"index out of range", triangle + "f 1 2 4", "", "model.obj", 4, 7},
+		{"bad index", triangle + "f 1 two 3", "", "model.obj", 4, 5},
+		{"missing index", triangle + "f 1 /1 3", "", "model.obj", 4, 5},
+		{"short face", triangle + "f 1 2", "", "model.obj", 4, 0},
+		{"usemtl without name", "usemtl", "", "model.obj", 1, 0},
+		{"usemtl with two names", "usemtl red green", "", "model.obj", 1, 0},
+		{"unknown material", "mtllib model.mtl\nusemtl  blue", "newmtl red", "model.obj", 2, 9},
+		{"material before its library", "usemtl red\nmtllib model.mtl", "newmtl red", "model.obj", 1, 8},
+		{"bad material library", "\nmtllib  model.mtl", "newmtl red\nKd 0.5 0.5", "model.obj", 2, 9},
+		{"missing material library", "mtllib model.mtl missing.mtl", "newmtl red", "model.obj", 1, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"model.obj": {Data: []byte(tt.obj)},
+				"model.mtl": {Data: []byte(tt.mtl)},
+			}
+			_, err := LoadOBJFS(fsys, "model.obj")
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("got %v, expected a ParseError", err)
+			}
+			if pe.File != tt.file || pe.Line != tt.line || pe.Column != tt.column {
+				t.Errorf("got %s:%d:%d, expected %s:%d:%d", pe.File, pe.Line, pe.Column, tt.file, tt.line, tt.column)
+			}
+		})
+	}
+}
+
+func TestLoadMTLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mtl    string
+		line   int
+		column int
+	}{
+		{"two value color", "newmtl red\nKd 0.5 0.5", 2, 0},
+		{"bad color", "newmtl red\nKa 1 x 1", 2, 6},
+		{"bad illumination model", "newmtl red\nillum two", 2, 7},
+		{"newmtl without name", "newmtl", 1, 0},
+		{"map_Kd without file", "newmtl red\nmap_Kd", 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"model.obj": {Data: []byte("mtllib model.mtl")},
+				"model.mtl": {Data: []byte(tt.mtl)},
+			}
+			_, err := LoadOBJFS(fsys, "model.obj")
+			var outer *ParseError
+			if !errors.As(err, &outer) {
+				t.Fatalf("got %v, expected a ParseError", err)
+			}
+			var pe *ParseError
+			if !errors.As(outer.Err, &pe) {
+				t.Fatalf("got %v, expected the material library's ParseError", outer.Err)
+			}
+			if pe.File != "model.mtl" || pe.Line != tt.line || pe.Column != tt.column {
+				t.Errorf("got %s:%d:%d, expected model.mtl:%d:%d", pe.File, pe.Line, pe.Column, tt.line, tt.column)
+			}
+		})
+	}
+}
+
+func TestMissingMaterialLibrary(t *testing.T) {
+	fsys := fstest.MapFS{"model.obj": {Data: []byte("mtllib missing.mtl")}}
+	_, err := LoadOBJFS(fsys, "model.obj")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, expected it to wrap fs.ErrNotExist", err)
+	}
+}
+
+func TestLoadMaterials(t *testing.T) {
+	fsys := fstest.MapFS{
+		"model.obj": {Data: []byte("mtllib model.mtl\n" + triangle +
+			"f 1 2 3\nusemtl red\nf 1 2 3\nf 3 2 1\nusemtl glass\nf 1 2 3\n")},
+		"model.mtl": {Data: []byte("newmtl red\nKa 0.1 0.2 0.3\nKd 0.8 0.1 0.1\nKs 0.5\nNs 32\nd 1\nillum 2\n" +
+			"map_Kd -s 1 1 1 red.png\n\nnewmtl glass # see through\nKd 0.2 0.4 0.6\nTr 0.25\n")},
+	}
+	red := Material{
+		Name:       "red",
+		Ambient:    RGB{R: 0.1, G: 0.2, B: 0.3},
+		Diffuse:    RGB{R: 0.8, G: 0.1, B: 0.1},
+		Specular:   RGB{R: 0.5, G: 0.5, B: 0.5},
+		Shininess:  32,
+		Dissolve:   1,
+		Illum:      2,
+		DiffuseMap: "red.png",
+	}
+	glass := Material{
+		Name:     "glass",
+		Diffuse:  RGB{R: 0.2, G: 0.4, B: 0.6},
+		Dissolve: 0.75,
+	}
+
+	shape, err := LoadOBJFS(fsys, "model.obj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Full white ambient light leaves each face its diffuse color, with dissolve as its alpha
+	shaded := shape.GetTriangles(Shade(NewLighting(RGB{R: 1, G: 1, B: 1})))
+	tests := []struct {
+		name     string
+		material *Material
+		color    uint32
+	}{
+		{"before usemtl", nil, 0xFFFFFFFF},
+		{"red", &red, red.Diffuse.pack(1)},
+		{"still red", &red, red.Diffuse.pack(1)},
+		{"glass", &glass, glass.Diffuse.pack(0.75)},
+	}
+	if len(shape.ts) != len(tests) || len(shaded) != len(tests) {
+		t.Fatalf("got %d triangles, %d shaded, expected %d", len(shape.ts), len(shaded), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shape.ts[i].material
+			switch {
+			case tt.material == nil && got != nil:
+				t.Errorf("got material %+v, expected none", *got)
+			case tt.material != nil && (got == nil || *got != *tt.material):
+				t.Errorf("got material %+v, expected %+v", got, *tt.material)
+			}
+			if c := shaded[i].color; c != tt.color {
+				t.Errorf("got color %08X, expected %08X", c, tt.color)
+			}
+		})
+	}
+	if shape.ts[1].material != shape.ts[2].material {
+		t.Error("expected faces after the same usemtl to share its material")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"os"
+)
+
+// Offscreen is a Renderer that draws into a software image buffer, without a window
+type Offscreen struct {
+	raster     *Rasterizer
+	background uint32
+}
+
+func NewOffscreen(width, height int) *Offscreen {
+	o := &Offscreen{
+		raster: NewRasterizer(width, height),
+	}
+	o.raster.Clear(0)
+	return o
+}
+
+// Clear sets the background each frame starts with and clears to it
+func (o *Offscreen) Clear(color uint32) {
+	o.background = color
+	o.raster.Clear(color)
+}
+
+func (o *Offscreen) Begin() {
+	o.raster.Clear(o.background)
+}
+
+func (o *Offscreen) DrawTriangles(vs []Vertex) {
+	o.raster.DrawTriangles(vs)
+}
+
+func (o *Offscreen) DrawPhong(ts []PhongTriangle) {
+	o.raster.DrawPhong(ts)
+}
+
+func (o *Offscreen) End() error {
+	return nil
+}
+
+func (o *Offscreen) Render(shape *Shape, stages ...Stage) {
+	Draw(o, shape.GetTriangles(stages...))
+}
+
+func (o *Offscreen) Image() *image.RGBA {
+	return o.raster.Image()
+}
+
+func WritePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+func SavePNG(filename string, img image.Image) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err = WritePNG(file, img); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
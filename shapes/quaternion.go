@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"math"
+)
+
+// Quaternion is a rotation. Angles follow the same handedness as RotationX, RotationY and
+// RotationZ, so QuaternionAxisAngle(NewVector(1, 0, 0), a).Matrix() equals RotationX(a), and
+// q1.Multiply(q2) applies q1 then q2 just like q1.Matrix().Multiply(q2.Matrix()).
+type Quaternion struct {
+	W float64
+	X float64
+	Y float64
+	Z float64
+}
+
+func NewQuaternion(w, x, y, z float64) *Quaternion {
+	return &Quaternion{W: w, X: x, Y: y, Z: z}
+}
+
+func IdentityQuaternion() *Quaternion {
+	return &Quaternion{W: 1}
+}
+
+func QuaternionAxisAngle(axis *Vector, angle float64) *Quaternion {
+	a := axis.Normalize()
+	s := math.Sin(angle / 2)
+	return &Quaternion{
+		W: math.Cos(angle / 2),
+		X: a.X * s,
+		Y: a.Y * s,
+		Z: a.Z * s,
+	}
+}
+
+// QuaternionEuler matches RotationX(x).Multiply(RotationY(y)).Multiply(RotationZ(z))
+func QuaternionEuler(x, y, z float64) *Quaternion {
+	return QuaternionAxisAngle(NewVector(1, 0, 0), x).
+		Multiply(QuaternionAxisAngle(NewVector(0, 1, 0), y)).
+		Multiply(QuaternionAxisAngle(NewVector(0, 0, 1), z))
+}
+
+// QuaternionMatrix extracts the rotation from the upper 3x3 of a matrix
+func QuaternionMatrix(m *Matrix4X4) *Quaternion {
+	var q Quaternion
+	switch trace := m[0][0] + m[1][1] + m[2][2]; {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = Quaternion{W: s / 4, X: (m[2][1] - m[1][2]) / s, Y: (m[0][2] - m[2][0]) / s, Z: (m[1][0] - m[0][1]) / s}
+	case m[0][0] > m[1][1] && m[0][0] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q = Quaternion{W: (m[2][1] - m[1][2]) / s, X: s / 4, Y: (m[0][1] + m[1][0]) / s, Z: (m[0][2] + m[2][0]) / s}
+	case m[1][1] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q = Quaternion{W: (m[0][2] - m[2][0]) / s, X: (m[0][1] + m[1][0]) / s, Y: s / 4, Z: (m[1][2] + m[2][1]) / s}
+	default:
+		s := 2 * math.Sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q = Quaternion{W: (m[1][0] - m[0][1]) / s, X: (m[0][2] + m[2][0]) / s, Y: (m[1][2] + m[2][1]) / s, Z: s / 4}
+	}
+	return q.Normalize()
+}
+
+func (q *Quaternion) Multiply(q1 *Quaternion) *Quaternion {
+	return &Quaternion{
+		W: q.W*q1.W - q.X*q1.X - q.Y*q1.Y - q.Z*q1.Z,
+		X: q.W*q1.X + q.X*q1.W + q.Y*q1.Z - q.Z*q1.Y,
+		Y: q.W*q1.Y - q.X*q1.Z + q.Y*q1.W + q.Z*q1.X,
+		Z: q.W*q1.Z + q.X*q1.Y - q.Y*q1.X + q.Z*q1.W,
+	}
+}
+
+func (q *Quaternion) DotProduct(q1 *Quaternion) float64 {
+	return q.W*q1.W + q.X*q1.X + q.Y*q1.Y + q.Z*q1.Z
+}
+
+func (q *Quaternion) Length() float64 {
+	return math.Sqrt(q.DotProduct(q))
+}
+
+func (q *Quaternion) Normalize() *Quaternion {
+	l := q.Length()
+	return &Quaternion{W: q.W / l, X: q.X / l, Y: q.Y / l, Z: q.Z / l}
+}
+
+func (q *Quaternion) Conjugate() *Quaternion {
+	return &Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Slerp interpolates along the shortest arc from q (t = 0) to q1 (t = 1)
+func (q *Quaternion) Slerp(q1 *Quaternion, t float64) *Quaternion {
+	dot := q.DotProduct(q1)
+	if dot < 0 {
+		q1 = &Quaternion{W: -q1.W, X: -q1.X, Y: -q1.Y, Z: -q1.Z}
+		dot = -dot
+	}
+
+	var s0, s1 float64
+	if dot > 0.9995 {
+		// Nearly parallel, so a linear blend avoids dividing by a vanishing sine
+		s0, s1 = 1-t, t
+	} else {
+		theta := math.Acos(dot)
+		sin := math.Sin(theta)
+		s0 = math.Sin((1-t)*theta) / sin
+		s1 = math.Sin(t*theta) / sin
+	}
+	return (&Quaternion{
+		W: q.W*s0 + q1.W*s1,
+		X: q.X*s0 + q1.X*s1,
+		Y: q.Y*s0 + q1.Y*s1,
+		Z: q.Z*s0 + q1.Z*s1,
+	}).Normalize()
+}
+
+func (q *Quaternion) AxisAngle() (*Vector, float64) {
+	n := q.Normalize()
+	s := math.Sqrt(1 - n.W*n.W)
+	if s < 1e-9 {
+		return NewVector(1, 0, 0), 0
+	}
+	return NewVector(n.X/s, n.Y/s, n.Z/s), 2 * math.Acos(min(1, max(-1, n.W)))
+}
+
+// Euler returns the x, y and z angles that QuaternionEuler would build this rotation from
+func (q *Quaternion) Euler() (float64, float64, float64) {
+	m := q.Matrix()
+	sy := min(1, max(-1, m[0][2]))
+	y := math.Asin(sy)
+	if math.Abs(sy) > 0.9999999 {
+		// Gimbal lock: x and z rotate about the same axis, so fold it all into x
+		return math.Atan2(m[1][0]*sy, m[1][1]), y, 0
+	}
+	return math.Atan2(-m[1][2], m[2][2]), y, math.Atan2(-m[0][1], m[0][0])
+}
+
+func (q *Quaternion) Matrix() *Matrix4X4 {
+	n := q.Normalize()
+	w, x, y, z := n.W, n.X, n.Y, n.Z
+	return &Matrix4X4{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y), 0},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x), 0},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	}
+}
+
+func (q *Quaternion) Rotate(v *Vector) *Vector {
+	return v.MatrixMultiply(q.Matrix())
+}
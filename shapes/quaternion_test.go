@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+// quaternionNear treats q and -q as equal, since both are the same rotation
+func quaternionNear(a, b *Quaternion) bool {
+	return math.Abs(math.Abs(a.DotProduct(b))-1) <= epsilon
+}
+
+func axisAngle(x, y, z, angle float64) *Quaternion {
+	return QuaternionAxisAngle(NewVector(x, y, z), angle)
+}
+
+func TestSlerp(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        *Quaternion
+		q1       *Quaternion
+		t        float64
+		expected *Quaternion
+	}{
+		{"start", axisAngle(0, 0, 1, 0.2), axisAngle(1, 1, 0, 1.4), 0, axisAngle(0, 0, 1, 0.2)},
+		{"end", axisAngle(0, 0, 1, 0.2), axisAngle(1, 1, 0, 1.4), 1, axisAngle(1, 1, 0, 1.4)},
+		{"midpoint", IdentityQuaternion(), axisAngle(0, 1, 0, 1.2), 0.5, axisAngle(0, 1, 0, 0.6)},
+		{"quarter", axisAngle(1, 0, 0, 0.4), axisAngle(1, 0, 0, 2.0), 0.25, axisAngle(1, 0, 0, 0.8)},
+		{"shortest path", IdentityQuaternion(), axisAngle(0, 0, 1, 3*math.Pi/2), 0.5, axisAngle(0, 0, 1, -math.Pi/4)},
+		{"negated end", IdentityQuaternion(), axisAngle(0, 1, 0, 1.2-2*math.Pi), 0.5, axisAngle(0, 1, 0, 0.6)},
+		{"nearly parallel", axisAngle(0, 1, 0, 0.5), axisAngle(0, 1, 0, 0.5001), 0.5, axisAngle(0, 1, 0, 0.50005)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.q.Slerp(tt.q1, tt.t)
+			if !quaternionNear(got, tt.expected) {
+				t.Errorf("got %v, expected %v", *got, *tt.expected)
+			}
+			if math.Abs(got.Length()-1) > epsilon {
+				t.Errorf("got length %v, expected a unit quaternion", got.Length())
+			}
+		})
+	}
+}
+
+func TestEulerRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		x, y, z float64
+		exact   bool // false under gimbal lock, where only the rotation is recoverable
+	}{
+		{"identity", 0, 0, 0, true},
+		{"x", 0.7, 0, 0, true},
+		{"y", 0, -0.9, 0, true},
+		{"z", 0, 0, 2.5, true},
+		{"mixed", 0.3, 1.1, -2.2, true},
+		{"negative", -2.8, -0.4, -0.1, true},
+		{"gimbal lock", 0.4, math.Pi / 2, 0.3, false},
+		{"negative gimbal lock", 0.4, -math.Pi / 2, -0.6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := QuaternionEuler(tt.x, tt.y, tt.z)
+			x, y, z := q.Euler()
+			if got := QuaternionEuler(x, y, z); !quaternionNear(got, q) {
+				t.Errorf("got rotation %v, expected %v", *got, *q)
+			}
+			if tt.exact && !vectorNear(NewVector(x, y, z), NewVector(tt.x, tt.y, tt.z)) {
+				t.Errorf("got angles %v, %v, %v, expected %v, %v, %v", x, y, z, tt.x, tt.y, tt.z)
+			}
+		})
+	}
+}
+
+func TestEulerMatchesMatrices(t *testing.T) {
+	x, y, z := 0.3, 1.1, -2.2
+	expected := RotationX(x).Multiply(RotationY(y)).Multiply(RotationZ(z))
+	if got := QuaternionEuler(x, y, z).Matrix(); !matrixNear(got, expected) {
+		t.Errorf("got %v, expected %v", *got, *expected)
+	}
+}
+
+func TestQuaternionMatrixRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *Quaternion
+	}{
+		{"identity", IdentityQuaternion()},
+		{"small", axisAngle(1, 2, 3, 0.4)},
+		{"half turn about x", axisAngle(1, 0, 0, math.Pi)},
+		{"half turn about y", axisAngle(0, 1, 0, math.Pi)},
+		{"half turn about z", axisAngle(0, 0, 1, math.Pi)},
+		{"near half turn", axisAngle(-1, 3, 2, 3.1)},
+		{"general", QuaternionEuler(-0.8, 0.5, 2.9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.q.Matrix()
+			got := QuaternionMatrix(m)
+			if !quaternionNear(got, tt.q) {
+				t.Errorf("got %v, expected %v", *got, *tt.q)
+			}
+			if !matrixNear(got.Matrix(), m) {
+				t.Errorf("got %v, expected %v", *got.Matrix(), *m)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+type Rasterizer struct {
+	width  int
+	height int
+	pixels []uint32  // packed RGBA8888
+	depth  []float32 // post-projection z, smaller is nearer
+}
+
+func NewRasterizer(width, height int) *Rasterizer {
+	return &Rasterizer{
+		width:  width,
+		height: height,
+		pixels: make([]uint32, width*height),
+		depth:  make([]float32, width*height),
+	}
+}
+
+func (r *Rasterizer) Width() int {
+	return r.width
+}
+
+func (r *Rasterizer) Height() int {
+	return r.height
+}
+
+func (r *Rasterizer) Pixels() []uint32 {
+	return r.pixels
+}
+
+// Image copies the color buffer into an image
+func (r *Rasterizer) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	for i, p := range r.pixels {
+		img.Pix[i*4+0] = uint8(p >> 24)
+		img.Pix[i*4+1] = uint8(p >> 16)
+		img.Pix[i*4+2] = uint8(p >> 8)
+		img.Pix[i*4+3] = uint8(p)
+	}
+	return img
+}
+
+func (r *Rasterizer) Clear(color uint32) {
+	inf := float32(math.Inf(1))
+	for i := range r.pixels {
+		r.pixels[i] = color
+		r.depth[i] = inf
+	}
+}
+
+func (r *Rasterizer) Draw(ts []*Triangle) {
+	for _, t := range ts {
+		r.DrawTriangle(t)
+	}
+}
+
+// DrawTriangles fills each consecutive triple of vertices
+func (r *Rasterizer) DrawTriangles(vs []Vertex) {
+	for i := 0; i+2 < len(vs); i += 3 {
+		r.DrawVertices(vs[i], vs[i+1], vs[i+2])
+	}
+}
+
+func (r *Rasterizer) DrawTriangle(t *Triangle) {
+	if t.shader != nil {
+		r.drawPhong(t.phong())
+		return
+	}
+	vs := t.GetVertices()
+	r.DrawVertices(vs[0], vs[1], vs[2])
+}
+
+// DrawPhong lights each triangle at every pixel with its Shader
+func (r *Rasterizer) DrawPhong(ts []PhongTriangle) {
+	for i := range ts {
+		r.drawPhong(&ts[i])
+	}
+}
+
+// DrawVertices fills a screen space triangle, blending differing vertex colors and filling
+// matching ones flat. Colors are blended in screen space, not corrected for perspective, so
+// they drift on large triangles that reach far in depth.
+func (r *Rasterizer) DrawVertices(v0, v1, v2 Vertex) {
+	color := packRGBA(v0.Color)
+	if v0.Color == v1.Color && v0.Color == v2.Color {
+		r.fill(v0, v1, v2, func(_, _, _ float64) uint32 {
+			return color
+		})
+		return
+	}
+	r.fill(v0, v1, v2, func(w0, w1, w2 float64) uint32 {
+		return blendColors(v0.Color, v1.Color, v2.Color, w0, w1, w2)
+	})
+}
+
+// drawPhong blends positions and normals across the triangle and lights each pixel with them.
+// Like DrawVertices, it blends in screen space, which is least accurate for point and spot
+// lights close to large triangles.
+func (r *Rasterizer) drawPhong(t *PhongTriangle) {
+	p, n := &t.Positions, &t.Normals
+	// Scratch space for the Shader, reused across pixels
+	position, normal := NewVector(0, 0, 0), NewVector(0, 0, 0)
+	r.fill(t.Vertices[0], t.Vertices[1], t.Vertices[2], func(w0, w1, w2 float64) uint32 {
+		position.X = w0*p[0].X + w1*p[1].X + w2*p[2].X
+		position.Y = w0*p[0].Y + w1*p[1].Y + w2*p[2].Y
+		position.Z = w0*p[0].Z + w1*p[1].Z + w2*p[2].Z
+		normal.X = w0*n[0].X + w1*n[1].X + w2*n[2].X
+		normal.Y = w0*n[0].Y + w1*n[1].Y + w2*n[2].Y
+		normal.Z = w0*n[0].Z + w1*n[1].Z + w2*n[2].Z
+		return packRGBA(t.Shader(position, normal))
+	})
+}
+
+// fill depth tests every pixel the triangle covers, coloring those it's nearest at with the
+// color shade gives for the pixel's barycentric weights
+func (r *Rasterizer) fill(v0, v1, v2 Vertex, shade func(w0, w1, w2 float64) uint32) {
+	area := edge(v0.X, v0.Y, v1.X, v1.Y, v2.X, v2.Y)
+	if area == 0 {
+		return
+	}
+
+	minX := max(0, int(math.Floor(min(v0.X, v1.X, v2.X))))
+	maxX := min(r.width-1, int(math.Ceil(max(v0.X, v1.X, v2.X))))
+	minY := max(0, int(math.Floor(min(v0.Y, v1.Y, v2.Y))))
+	maxY := min(r.height-1, int(math.Ceil(max(v0.Y, v1.Y, v2.Y))))
+
+	for y := minY; y <= maxY; y++ {
+		py := float64(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float64(x) + 0.5
+			// Barycentric weights, normalised so either winding order is inside
+			w0 := edge(v1.X, v1.Y, v2.X, v2.Y, px, py) / area
+			w1 := edge(v2.X, v2.Y, v0.X, v0.Y, px, py) / area
+			w2 := edge(v0.X, v0.Y, v1.X, v1.Y, px, py) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			z := float32(w0*v0.Z + w1*v1.Z + w2*v2.Z)
+			i := y*r.width + x
+			if z >= r.depth[i] {
+				continue
+			}
+			r.depth[i] = z
+			r.pixels[i] = shade(w0, w1, w2)
+		}
+	}
+}
+
+func blendColors(c0, c1, c2 color.RGBA, w0, w1, w2 float64) uint32 {
+	channel := func(a, b, c uint8) uint32 {
+		return uint32(min(255, max(0, math.Round(w0*float64(a)+w1*float64(b)+w2*float64(c)))))
+	}
+	return channel(c0.R, c1.R, c2.R)<<24 | channel(c0.G, c1.G, c2.G)<<16 |
+		channel(c0.B, c1.B, c2.B)<<8 | channel(c0.A, c1.A, c2.A)
+}
+
+func edge(ax, ay, bx, by, px, py float64) float64 {
+	return (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+}
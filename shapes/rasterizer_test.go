@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"image/color"
+	"testing"
+)
+
+const (
+	backgroundColor = uint32(0x232323FF)
+	nearColor       = uint32(0xFF0000FF)
+	farColor        = uint32(0x0000FFFF)
+)
+
+// square covers the whole of an 8x8 raster, at a single depth, in two triangles
+func square(z float64, c uint32) [][3]Vertex {
+	v := func(x, y float64) Vertex {
+		return Vertex{X: x, Y: y, Z: z, Color: unpackRGBA(c)}
+	}
+	return [][3]Vertex{
+		{v(0, 0), v(8, 0), v(0, 8)},
+		{v(8, 0), v(8, 8), v(0, 8)},
+	}
+}
+
+func TestDrawVerticesDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		first [][3]Vertex
+		then  [][3]Vertex
+	}{
+		{"near first", square(0.2, nearColor), square(0.7, farColor)},
+		{"far first", square(0.7, farColor), square(0.2, nearColor)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRasterizer(8, 8)
+			r.Clear(backgroundColor)
+			for _, vs := range append(tt.first, tt.then...) {
+				r.DrawVertices(vs[0], vs[1], vs[2])
+			}
+			for i, p := range r.Pixels() {
+				if p != nearColor {
+					t.Fatalf("pixel %d: got %08X, expected the nearer %08X", i, p, nearColor)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawVerticesCoverage(t *testing.T) {
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+	tests := []struct {
+		name    string
+		v0      Vertex
+		v1      Vertex
+		v2      Vertex
+		covered map[[2]int]bool // pixels expected to be drawn, checked along with a few that aren't
+	}{
+		{
+			"small",
+			Vertex{X: 1, Y: 1, Color: red}, Vertex{X: 4, Y: 1, Color: red}, Vertex{X: 1, Y: 4, Color: red},
+			map[[2]int]bool{{1, 1}: true, {2, 1}: true, {1, 2}: true, {5, 5}: false, {0, 0}: false, {7, 7}: false},
+		},
+		{
+			"reversed winding",
+			Vertex{X: 1, Y: 1, Color: red}, Vertex{X: 1, Y: 4, Color: red}, Vertex{X: 4, Y: 1, Color: red},
+			map[[2]int]bool{{1, 1}: true, {2, 1}: true, {5, 5}: false},
+		},
+		{
+			"past the edges",
+			Vertex{X: -20, Y: -20, Color: red}, Vertex{X: 30, Y: -20, Color: red}, Vertex{X: -20, Y: 30, Color: red},
+			map[[2]int]bool{{0, 0}: true, {7, 0}: true, {0, 7}: true, {3, 3}: true},
+		},
+		{
+			"degenerate",
+			Vertex{X: 1, Y: 1, Color: red}, Vertex{X: 3, Y: 3, Color: red}, Vertex{X: 6, Y: 6, Color: red},
+			map[[2]int]bool{{1, 1}: false, {3, 3}: false, {5, 5}: false},
+		},
+		{
+			"outside",
+			Vertex{X: 10, Y: 10, Color: red}, Vertex{X: 20, Y: 10, Color: red}, Vertex{X: 10, Y: 20, Color: red},
+			map[[2]int]bool{{7, 7}: false, {0, 0}: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRasterizer(8, 8)
+			r.Clear(backgroundColor)
+			r.DrawVertices(tt.v0, tt.v1, tt.v2)
+			for xy, covered := range tt.covered {
+				expected := backgroundColor
+				if covered {
+					expected = packRGBA(red)
+				}
+				if got := r.Pixels()[xy[1]*8+xy[0]]; got != expected {
+					t.Errorf("pixel %v: got %08X, expected %08X", xy, got, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"image/color"
+)
+
+// Vertex is a backend neutral screen space vertex. Z is the post-projection depth, smaller
+// being nearer, and U, V are texture coordinates.
+type Vertex struct {
+	X     float64
+	Y     float64
+	Z     float64
+	Color color.RGBA
+	U     float64
+	V     float64
+}
+
+// Renderer draws a frame of screen space triangles, given as consecutive vertex triples
+type Renderer interface {
+	Begin()
+	DrawTriangles(vs []Vertex)
+	End() error
+}
+
+// PhongTriangle is a screen space triangle lit at every pixel by its Shader, from the view
+// space positions and normals blended across it. Calling back into Go for each pixel only
+// suits software rasterizers, so it's kept apart from Vertex.
+type PhongTriangle struct {
+	Vertices  [3]Vertex
+	Positions [3]Vector
+	Normals   [3]Vector
+	Shader    Shader
+}
+
+// PhongRenderer is a Renderer that can also light triangles at every pixel
+type PhongRenderer interface {
+	Renderer
+	DrawPhong(ts []PhongTriangle)
+}
+
+// Vertices lists the triangles' vertices, with Phong shaded ones lit at each vertex instead
+func Vertices(ts []*Triangle) []Vertex {
+	vs := make([]Vertex, 0, len(ts)*3)
+	for _, t := range ts {
+		vs = append(vs, t.GetVertices()...)
+	}
+	return vs
+}
+
+// Draw hands the triangles to the renderer, keeping Phong shading for renderers that can
+// light each pixel and otherwise lighting at the vertices, as Gouraud shading does
+func Draw(r Renderer, ts []*Triangle) {
+	pr, ok := r.(PhongRenderer)
+	if !ok {
+		r.DrawTriangles(Vertices(ts))
+		return
+	}
+	vs := make([]Vertex, 0, len(ts)*3)
+	var phong []PhongTriangle
+	for _, t := range ts {
+		if t.shader != nil {
+			phong = append(phong, *t.phong())
+		} else {
+			vs = append(vs, t.GetVertices()...)
+		}
+	}
+	pr.DrawTriangles(vs)
+	if len(phong) > 0 {
+		pr.DrawPhong(phong)
+	}
+}
+
+// NullRenderer discards everything it is given, counting the triangles
+type NullRenderer struct {
+	Triangles int
+}
+
+func (n *NullRenderer) Begin() {
+	n.Triangles = 0
+}
+
+func (n *NullRenderer) DrawTriangles(vs []Vertex) {
+	n.Triangles += len(vs) / 3
+}
+
+func (n *NullRenderer) End() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"image/color"
+)
+
+type Shading int
+
+const (
+	ShadingFlat    Shading = iota // one color per face, from the face normal
+	ShadingGouraud                // lit at each vertex, with the colors blended across the face
+	ShadingPhong                  // normals blended across the face and lit at each pixel
+)
+
+// Shader lights a pixel given its interpolated view space position and normal. The vectors
+// are reused for the next pixel, so the Shader mustn't keep them.
+type Shader func(position, normal *Vector) color.RGBA
+
+func unpackRGBA(c uint32) color.RGBA {
+	return color.RGBA{
+		R: uint8(c >> 24),
+		G: uint8(c >> 16),
+		B: uint8(c >> 8),
+		A: uint8(c),
+	}
+}
+
+func packRGBA(c color.RGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// smoothNormals gives every vertex without a normal the average of the normals of the faces
+// sharing its position, weighted by their area
+func smoothNormals(ts []*Triangle) {
+	type position struct{ x, y, z float64 }
+	sums := map[position]*Vector{}
+	for _, t := range ts {
+		n := t.vectors[1].Subtract(t.vectors[0]).CrossProduct(t.vectors[2].Subtract(t.vectors[0]))
+		for _, v := range t.vectors {
+			p := position{v.X, v.Y, v.Z}
+			if sum, ok := sums[p]; ok {
+				sums[p] = sum.Add(n)
+			} else {
+				sums[p] = n
+			}
+		}
+	}
+	for _, t := range ts {
+		for i, v := range t.vectors {
+			if t.normals[i] == nil {
+				if n := sums[position{v.X, v.Y, v.Z}]; n.Length() > 0 {
+					t.normals[i] = n.Normalize()
+				}
+			}
+		}
+	}
+}
+
+// transformNormals moves normals as directions, ignoring translation, and renormalizes them
+func transformNormals(normals [3]*Vector, normalMatrix *Matrix4X4) [3]*Vector {
+	var ns [3]*Vector
+	for i, n := range normals {
+		if n != nil {
+			ns[i] = NewVectorW(n.X, n.Y, n.Z, 0).MatrixMultiply(normalMatrix).Normalize()
+		}
+	}
+	return ns
+}
@@ -5,26 +5,37 @@
 package shapes
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
+	"math"
 )
 
 type Shape struct {
-	ts       []*Triangle
-	location *Vector
-	rotation *Vector
-	scale    *Vector
-	color    sdl.Color
+	ts          []*Triangle
+	location    *Vector
+	orientation *Quaternion
+	scale       *Vector
+	shading     Shading
+}
+
+func newShape(ts []*Triangle) *Shape {
+	smoothNormals(ts)
+	return &Shape{
+		ts:          ts,
+		location:    NewVector(0, 0, 0),
+		orientation: IdentityQuaternion(),
+		scale:       NewVector(1, 1, 1),
+	}
 }
 
 func (s *Shape) duplicate() *Shape {
 	s2 := &Shape{
-		ts:       make([]*Triangle, len(s.ts)),
-		location: NewVector(s.location.X, s.location.Y, s.location.Z),
-		rotation: NewVector(s.rotation.X, s.rotation.Y, s.rotation.Z),
-		scale:    NewVector(s.scale.X, s.scale.Y, s.scale.Z),
+		ts:          make([]*Triangle, len(s.ts)),
+		location:    NewVector(s.location.X, s.location.Y, s.location.Z),
+		orientation: NewQuaternion(s.orientation.W, s.orientation.X, s.orientation.Y, s.orientation.Z),
+		scale:       NewVector(s.scale.X, s.scale.Y, s.scale.Z),
+		shading:     s.shading,
 	}
 	for i, t := range s.ts {
-		s2.ts[i] = NewTriangle(t.vectors[0], t.vectors[1], t.vectors[2], t.color)
+		s2.ts[i] = t.duplicate()
 	}
 	return s2
 }
@@ -35,25 +46,71 @@ func (s *Shape) Locate(x, y, z float64) *Shape {
 }
 
 func (s *Shape) Rotate(x, y, z float64) *Shape {
-	s.rotation = NewVector(x, y, z)
+	s.orientation = QuaternionEuler(x, y, z)
 	return s
 }
 
+func (s *Shape) Orient(q *Quaternion) *Shape {
+	s.orientation = q.Normalize()
+	return s
+}
+
+func (s *Shape) Orientation() *Quaternion {
+	return s.orientation
+}
+
 func (s *Shape) Scale(x, y, z float64) *Shape {
 	s.scale = NewVector(x, y, z)
 	return s
 }
 
-func (s *Shape) GetTriangles(transforms ...Transformations) []*Triangle {
+func (s *Shape) Shading(shading Shading) *Shape {
+	s.shading = shading
+	return s
+}
+
+// Model builds the model matrix: scale, then rotation, then translation
+func (s *Shape) Model() *Matrix4X4 {
+	return Scaling(s.scale.X, s.scale.Y, s.scale.Z).
+		Multiply(s.orientation.Matrix()).
+		Multiply(Translation(s.location.X, s.location.Y, s.location.Z))
+}
+
+// Bounds returns the lowest and highest corners of the world space box around the shape
+func (s *Shape) Bounds() (*Vector, *Vector) {
+	model := s.Model()
+	lo := NewVector(math.Inf(1), math.Inf(1), math.Inf(1))
+	hi := NewVector(math.Inf(-1), math.Inf(-1), math.Inf(-1))
+	for _, t := range s.ts {
+		for _, v := range t.vectors {
+			w := v.MatrixMultiply(model)
+			lo = NewVector(min(lo.X, w.X), min(lo.Y, w.Y), min(lo.Z, w.Z))
+			hi = NewVector(max(hi.X, w.X), max(hi.Y, w.Y), max(hi.Z, w.Z))
+		}
+	}
+	return lo, hi
+}
+
+func (s *Shape) GetTriangles(stages ...Stage) []*Triangle {
+	stages = append([]Stage{WorldMatrices(s.Model())}, stages...)
 	ts := make([]*Triangle, 0, len(s.ts))
 	for _, t := range s.ts {
-		t.visible = true
-		t2 := t
-		for _, transform := range transforms {
-			t2 = transform(t2)
+		// Start from a copy so views rendering the same shape at once don't share state
+		start := *t
+		start.visible = true
+		start.shading = s.shading
+		t2s := []*Triangle{&start}
+		for _, stage := range stages {
+			var next []*Triangle
+			for _, t2 := range t2s {
+				next = append(next, stage.Apply(t2)...)
+			}
+			t2s = next
 		}
-		if t2.visible {
-			ts = append(ts, t2)
+		for _, t2 := range t2s {
+			if t2.visible {
+				ts = append(ts, t2)
+			}
 		}
 	}
 	return ts
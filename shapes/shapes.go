@@ -5,49 +5,127 @@
 package shapes
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
-	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
 
-	"github.com/veandco/go-sdl2/sdl"
+	"g3-engine/resources"
 )
 
-var projectionMatrix *Matrix4X4
+type Loader func() (*Shape, error)
 
+// Shapes is a registry of named shapes, each loaded on first use and cached
 type Shapes struct {
-	shapes map[string]*Shape
+	mu      sync.Mutex
+	entries map[string]*entry
+	roots   []fs.FS
 }
 
-func LoadShapes(pm *Matrix4X4) *Shapes {
-	projectionMatrix = pm
-	s := &Shapes{
-		shapes: map[string]*Shape{},
+// entry is a registered loader and, once it has succeeded, the shape it loaded
+type entry struct {
+	loader Loader
+	shape  *Shape
+}
+
+type Option func(*Shapes)
+
+// SearchPath adds directories to look for models in, ahead of the bundled ones
+func SearchPath(dirs ...string) Option {
+	return func(s *Shapes) {
+		for _, dir := range dirs {
+			s.roots = append(s.roots, os.DirFS(dir))
+		}
 	}
-	s.shapes["cube"] = createCube()
-	s.shapes["spaceship"] = loadObject("spaceship.obj")
-	s.shapes["teapot"] = loadObject("teapot.obj")
-	s.shapes["axis"] = loadObject("axis.obj")
-	return s
 }
 
-func (s *Shapes) Cube() *Shape {
-	return s.shapes["cube"].duplicate()
+// FileSystem adds a file system to look for models in, ahead of the bundled ones
+func FileSystem(fsys fs.FS) Option {
+	return func(s *Shapes) {
+		s.roots = append(s.roots, fsys)
+	}
 }
 
-func (s *Shapes) Spaceship() *Shape {
-	return s.shapes["spaceship"].duplicate()
+// LoadShapes registers the cube and every .obj model found in the search roots
+func LoadShapes(options ...Option) (*Shapes, error) {
+	s := &Shapes{
+		entries: map[string]*entry{},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	bundled, err := fs.Sub(resources.Objects, "objects")
+	if err != nil {
+		return nil, err
+	}
+	s.roots = append(s.roots, bundled)
+
+	s.entries["cube"] = &entry{loader: func() (*Shape, error) {
+		return createCube(), nil
+	}}
+	for _, root := range s.roots {
+		filenames, err := fs.Glob(root, "*.obj")
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range filenames {
+			name := strings.TrimSuffix(filename, ".obj")
+			if _, ok := s.entries[name]; !ok {
+				s.entries[name] = &entry{loader: s.objectLoader(filename)}
+			}
+		}
+	}
+	return s, nil
 }
 
-func (s *Shapes) Teapot() *Shape {
-	return s.shapes["teapot"].duplicate()
+// Register adds a shape, replacing any existing shape of the same name
+func (s *Shapes) Register(name string, loader Loader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[name] = &entry{loader: loader}
 }
 
-func (s *Shapes) Axis() *Shape {
-	return s.shapes["axis"].duplicate()
+// Get returns a fresh copy of the named shape, loading it if this is its first use. The
+// registry isn't locked while loading, so loaders may Get other shapes.
+func (s *Shapes) Get(name string) (*Shape, error) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("unknown shape %q", name)
+	}
+	shape := e.shape
+	s.mu.Unlock()
+
+	if shape == nil {
+		var err error
+		if shape, err = e.loader(); err != nil {
+			return nil, fmt.Errorf("unable to load shape %q: %w", name, err)
+		}
+		// Keep whichever load finished first if two raced
+		s.mu.Lock()
+		if e.shape == nil {
+			e.shape = shape
+		}
+		shape = e.shape
+		s.mu.Unlock()
+	}
+	return shape.duplicate(), nil
+}
+
+// List returns the names of all registered shapes, sorted
+func (s *Shapes) List() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func createCube() *Shape {
@@ -91,14 +169,9 @@ func createCube() *Shape {
 		0x0000FFFF,
 		0xFF00FFFF,
 	}
-	s := &Shape{
-		ts:       make([]*Triangle, len(idx)/4),
-		location: NewVector(0, 0, 0),
-		rotation: NewVector(0, 0, 0),
-		scale:    NewVector(1, 1, 1),
-	}
-	for i := 0; i < len(idx)/4; i++ {
-		s.ts[i] = NewTriangle(
+	ts := make([]*Triangle, len(idx)/4)
+	for i := range ts {
+		ts[i] = NewTriangle(
 			pts[idx[i*4+0]],
 			pts[idx[i*4+1]],
 			pts[idx[i*4+2]],
@@ -106,107 +179,19 @@ func createCube() *Shape {
 		)
 	}
 
-	return s
+	return newShape(ts)
 }
 
-func loadObject(filename string) *Shape {
-	dir, err := os.Getwd()
-	if err != nil {
-		panic(fmt.Errorf("unable to get working directory: %w", err))
-	}
-
-	var file *os.File
-	base := filepath.Join(dir, "resources", "objects")
-	file, err = os.Open(filepath.Join(base, filename))
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var pts []*Vector
-	var ts []*Triangle
-
-	lineCnt := 0
-	for scanner.Scan() {
-		lineCnt++
-		line := scanner.Text()
-		if len(line) == 0 {
-			continue
-		}
-		switch line[:2] {
-		case "v ":
-			pts = append(pts, parseVector(line[2:], lineCnt))
-		case "f ":
-			ts = append(ts, parseFace(pts, line[2:], lineCnt))
+// objectLoader loads the model from the first root that has it
+func (s *Shapes) objectLoader(filename string) Loader {
+	return func() (*Shape, error) {
+		for _, root := range s.roots {
+			// A missing material library also wraps fs.ErrNotExist, so check for the model itself
+			if _, err := fs.Stat(root, filename); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return LoadOBJFS(root, filename)
 		}
-	}
-
-	if err = scanner.Err(); err != nil {
-		log.Fatal(err)
-	}
-	return &Shape{
-		ts:       ts,
-		location: NewVector(0, 0, 0),
-		rotation: NewVector(0, 0, 0),
-		scale:    NewVector(1, 1, 1),
-		color:    sdl.Color{R: uint8(0xff), G: uint8(0xff), B: uint8(0xff), A: uint8(0xff)},
-	}
-}
-
-func parseVector(line string, lineCnt int) *Vector {
-	xyz := strings.Split(line, " ")
-	if len(xyz) != 3 && len(xyz) != 4 {
-		log.Panicf("Bad vector in line %d: %s", lineCnt, line)
-	}
-	var x, y, z float64
-	var err error
-
-	x, err = strconv.ParseFloat(xyz[0], 32)
-	if err != nil {
-		log.Panicf("Bad x in line %d: %s", lineCnt, line)
-	}
-
-	y, err = strconv.ParseFloat(xyz[1], 32)
-	if err != nil {
-		log.Panicf("Bad Y in line %d: %s", lineCnt, line)
-	}
-
-	z, err = strconv.ParseFloat(xyz[2], 32)
-	if err != nil {
-		log.Panicf("Bad Z in line %d: %s", lineCnt, line)
-	}
-
-	return NewVector(x, y, z)
-}
-
-func parseFace(pts []*Vector, line string, lineCnt int) *Triangle {
-	xyz := strings.Split(line, " ")
-	if len(xyz) != 3 {
-		log.Panicf("Bad face in line %d: %s", lineCnt, line)
-	}
-	var x, y, z int64
-	var err error
-
-	x, err = strconv.ParseInt(xyz[0], 10, 32)
-	if err != nil {
-		log.Panicf("Bad x in line %d: %s", lineCnt, line)
-	}
-
-	y, err = strconv.ParseInt(xyz[1], 10, 32)
-	if err != nil {
-		log.Panicf("Bad Y in line %d: %s", lineCnt, line)
-	}
-
-	z, err = strconv.ParseInt(xyz[2], 10, 32)
-	if err != nil {
-		log.Panicf("Bad Z in line %d: %s", lineCnt, line)
-	}
-
-	return &Triangle{
-		vectors: [3]*Vector{pts[x-1], pts[y-1], pts[z-1]},
-		normal:  NewVector(0, 0, 0),
-		visible: true,
-		color:   uint32(0xFFFFFFFF),
+		return nil, fmt.Errorf("unable to find %s: %w", filename, fs.ErrNotExist)
 	}
 }
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2023 by Jason Figge
+ */
+
+package shapes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoaderGetsAnotherShape(t *testing.T) {
+	s, err := LoadShapes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Register("cubes", func() (*Shape, error) {
+		cube, err := s.Get("cube")
+		if err != nil {
+			return nil, err
+		}
+		return newShape(append(cube.ts, cube.ts...)), nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		cubes, err := s.Get("cubes")
+		if err == nil && len(cubes.ts) != 24 {
+			err = errors.New("wrong triangle count")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get deadlocked on a loader that calls Get")
+	}
+}
+
+func TestGetLoadsOnce(t *testing.T) {
+	s, err := LoadShapes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads := 0
+	s.Register("counted", func() (*Shape, error) {
+		loads++
+		return createCube(), nil
+	})
+	for i := 0; i < 3; i++ {
+		if _, err := s.Get("counted"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, expected once", loads)
+	}
+
+	s.Register("counted", func() (*Shape, error) {
+		loads++
+		return createCube(), nil
+	})
+	if _, err := s.Get("counted"); err != nil {
+		t.Fatal(err)
+	}
+	if loads != 2 {
+		t.Errorf("loaded %d times, expected Register to force a reload", loads)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	s, err := LoadShapes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("failed")
+	s.Register("broken", func() (*Shape, error) {
+		return nil, failed
+	})
+	tests := []struct {
+		name    string
+		wrapped error
+	}{
+		{"unknown", nil},
+		{"broken", failed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Get(tt.name)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.wrapped != nil && !errors.Is(err, tt.wrapped) {
+				t.Errorf("got %v, expected it to wrap %v", err, tt.wrapped)
+			}
+		})
+	}
+}
+
+func TestSearchRoots(t *testing.T) {
+	models := map[string]string{
+		"teapot.obj": triangle + "f 1 2 3",
+		"extra.obj":  triangle + "v 1 1 0\nf 1 2 4 3",
+	}
+	dir := t.TempDir()
+	fsys := fstest.MapFS{}
+	for name, obj := range models {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(obj), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fsys[name] = &fstest.MapFile{Data: []byte(obj)}
+	}
+	shadowed := fstest.MapFS{"teapot.obj": {Data: []byte(triangle + "v 1 1 0\nv 1 1 1\nf 1 2 4 5 3")}}
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{"search path", []Option{SearchPath(dir)}},
+		{"file system", []Option{FileSystem(fsys)}},
+		{"first root wins", []Option{FileSystem(fsys), FileSystem(shadowed)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := LoadShapes(tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := s.List()
+			for _, name := range []string{"cube", "teapot", "spaceship", "extra"} {
+				if !slices.Contains(names, name) {
+					t.Errorf("got %v, expected it to include %s", names, name)
+				}
+			}
+			for name, triangles := range map[string]int{"teapot": 1, "extra": 2} {
+				shape, err := s.Get(name)
+				if err != nil {
+					t.Fatalf("unable to get %s: %v", name, err)
+				}
+				if len(shape.ts) != triangles {
+					t.Errorf("%s: got %d triangles, expected %d from the added root", name, len(shape.ts), triangles)
+				}
+			}
+		})
+	}
+}